@@ -3,7 +3,9 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/parker/ParkerCli/internal/builder"
 	"github.com/parker/ParkerCli/internal/config"
@@ -24,11 +26,24 @@ var BuildCommand = &cli.Command{
 				&cli.StringFlag{Name: "arch", Value: runtime.GOARCH, Usage: "目标架构 (amd64, arm64)"},
 				&cli.StringFlag{Name: "version", Value: "", Usage: "版本号，会注入到二进制中"},
 				&cli.StringFlag{Name: "main", Value: "main.go", Usage: "主文件路径"},
-				&cli.StringFlag{Name: "ldflags", Value: "", Usage: "链接标志参数"},
-				&cli.StringFlag{Name: "tags", Value: "", Usage: "构建标签"},
+				&cli.StringFlag{Name: "ldflags", Value: "", Usage: "额外的链接参数，如 -X main.Mode=prod，与构建配置中的参数合并"},
+				&cli.StringFlag{Name: "tags", Value: "", Usage: "构建标签，逗号分隔"},
+				&cli.StringFlag{Name: "gcflags", Value: "", Usage: "编译参数，如 all=-N -l"},
+				&cli.StringFlag{Name: "buildmode", Value: "", Usage: "构建模式，如 pie"},
 				&cli.BoolFlag{Name: "static", Usage: "启用静态链接"},
+				&cli.BoolFlag{Name: "trimpath", Usage: "移除二进制中的本地路径"},
+				&cli.BoolFlag{Name: "race", Usage: "启用竞态检测"},
 				&cli.BoolFlag{Name: "debug", Usage: "保留调试信息"},
 				&cli.BoolFlag{Name: "clean", Usage: "清理旧文件重新构建"},
+				&cli.BoolFlag{Name: "no-cache", Usage: "不使用构建缓存"},
+				&cli.StringFlag{Name: "profile", Usage: "使用配置文件 build.profiles 中的构建配置，命令行参数优先"},
+				&cli.BoolFlag{Name: "all", Usage: "构建 build.profiles 中的所有配置"},
+				&cli.IntFlag{Name: "parallel", Aliases: []string{"p"}, Value: runtime.NumCPU(), Usage: "构建多个目标时同时构建的数量"},
+				&cli.BoolFlag{Name: "cmds", Usage: "构建 --cmd-dir 目录下的所有 main 包，二进制以所在目录命名"},
+				&cli.StringFlag{Name: "cmd-dir", Value: builder.DefaultMainsDir, Usage: "查找 main 包的目录"},
+				&cli.StringSliceFlag{Name: "include", Usage: "只构建名称匹配的 main 包，支持通配符，可多次指定"},
+				&cli.StringSliceFlag{Name: "exclude", Usage: "不构建名称匹配的 main 包，支持通配符，可多次指定"},
+				&cli.BoolFlag{Name: "compress", Usage: "将二进制与README、LICENSE打包为tar.gz(Windows为zip)并生成checksums.txt"},
 			},
 			Action: buildCodeAction,
 		},
@@ -41,6 +56,14 @@ var BuildCommand = &cli.Command{
 				&cli.StringFlag{Name: "file", Value: "Dockerfile", Usage: "Dockerfile路径"},
 				&cli.StringFlag{Name: "version", Value: "", Usage: "版本号"},
 				&cli.BoolFlag{Name: "no-cache", Usage: "禁用构建缓存"},
+				&cli.StringSliceFlag{Name: "platform", Usage: "目标平台，如 linux/amd64,linux/arm64，使用 buildx 构建"},
+				&cli.BoolFlag{Name: "push", Usage: "构建后推送到镜像仓库，使用配置中的 docker.username/password 登录"},
+				&cli.StringSliceFlag{Name: "build-arg", Usage: "构建参数，格式为 KEY=VALUE，可多次指定"},
+				&cli.StringFlag{Name: "target", Usage: "多阶段构建的目标阶段"},
+				&cli.StringSliceFlag{Name: "label", Usage: "镜像标签，格式为 KEY=VALUE，可多次指定"},
+				&cli.StringFlag{Name: "cache-from", Usage: "构建缓存来源，如 type=registry,ref=registry/app:cache"},
+				&cli.StringFlag{Name: "cache-to", Usage: "构建缓存导出位置，如 type=registry,ref=registry/app:cache,mode=max"},
+				&cli.StringFlag{Name: "builder", Usage: "使用的 buildx 构建器"},
 			},
 			Action: buildImageAction,
 		},
@@ -56,33 +79,23 @@ func buildCodeAction(c *cli.Context) error {
 	// 创建构建器
 	b := builder.NewStandardBuilder()
 
+	// 构建 cmd 目录下的所有 main 包
+	if c.Bool("cmds") {
+		return buildMainsAction(c, b)
+	}
+
+	// 使用配置中的构建配置
+	if c.Bool("all") || c.String("profile") != "" {
+		return buildProfilesAction(c, b)
+	}
+
 	// 获取默认构建选项
 	opts := builder.GetDefaultBuildOptions(builder.TypeBinary)
 
 	// 更新构建选项
-	opts.OutputPath = c.String("output")
-	if c.String("name") != "" {
-		opts.Name = c.String("name")
-	}
-	opts.GoOS = c.String("os")
-	opts.GoArch = c.String("arch")
-	if c.String("version") != "" {
-		opts.Version = c.String("version")
-	}
-	opts.MainFile = c.String("main")
-	opts.Tags = c.String("tags")
-	opts.Debug = c.Bool("debug")
-	opts.CleanBuild = c.Bool("clean")
-
-	// 处理ldflags
-	ldflags := c.String("ldflags")
-	if c.Bool("static") {
-		if ldflags != "" {
-			ldflags += " "
-		}
-		ldflags += "-extldflags '-static'"
+	if _, err := applyBuildFlags(c, &opts); err != nil {
+		return err
 	}
-	opts.LDFlags = ldflags
 
 	// 执行构建
 	ctx := context.Background()
@@ -94,9 +107,180 @@ func buildCodeAction(c *cli.Context) error {
 	// 输出构建结果
 	fmt.Println(builder.FormatBuildResult(result, builder.TypeBinary))
 
+	if result.ArchivePath != "" {
+		checksums, err := builder.WriteChecksums(opts.OutputPath, []string{result.ArchivePath})
+		if err != nil {
+			return err
+		}
+		fmt.Println("校验和清单:", checksums)
+	}
+
+	return nil
+}
+
+// buildProfilesAction 按 build.profiles 中的配置并行构建，--all 构建所有配置
+func buildProfilesAction(c *cli.Context, b *builder.StandardBuilder) error {
+	names := []string{c.String("profile")}
+	if c.Bool("all") {
+		if c.IsSet("name") {
+			return fmt.Errorf("--all 不能与 --name 同时使用")
+		}
+		names = builder.ProfileNames()
+		if len(names) == 0 {
+			return fmt.Errorf("未配置构建配置，请在配置文件的 build.profiles 中添加")
+		}
+	}
+
+	var builds []builder.BuildOptions
+	for _, name := range names {
+		profile, err := builder.Profile(name)
+		if err != nil {
+			return err
+		}
+
+		opts := builder.GetDefaultBuildOptions(builder.TypeBinary)
+		platforms, err := builder.ApplyProfile(&opts, profile)
+		if err != nil {
+			return fmt.Errorf("构建配置 %s 无效: %w", name, err)
+		}
+
+		// 命令行参数优先于构建配置
+		platformSet, err := applyBuildFlags(c, &opts)
+		if err != nil {
+			return err
+		}
+		if platformSet {
+			platforms = nil
+		}
+		// 未指定输出文件名时二进制使用应用名称，输出到以配置名称命名的子目录，避免多个配置的输出互相覆盖
+		if profile.Output == "" && !c.IsSet("name") {
+			opts.OutputPath = filepath.Join(opts.OutputPath, name)
+		}
+		builds = append(builds, builder.ExpandPlatforms(opts, platforms)...)
+	}
+
+	// 所有目标注入同一份git提交和构建时间信息
+	ctx := context.Background()
+	info := builder.CollectBuildInfo(ctx)
+	for i := range builds {
+		builds[i].BuildInfo = &info
+	}
+
+	fmt.Printf("构建 %s，共 %d 个目标\n", strings.Join(names, ", "), len(builds))
+	results, err := b.BuildBinaries(ctx, builds, c.Int("parallel"))
+	return printBuildResults(c, results, err)
+}
+
+// buildMainsAction 查找 --cmd-dir 下的所有 main 包并并行构建
+func buildMainsAction(c *cli.Context, b *builder.StandardBuilder) error {
+	if c.Bool("all") || c.IsSet("profile") {
+		return fmt.Errorf("--cmds 不能与 --all、--profile 同时使用")
+	}
+	if c.IsSet("name") || c.IsSet("main") {
+		return fmt.Errorf("--cmds 按目录名命名二进制，不能与 --name、--main 同时使用")
+	}
+
+	opts := builder.GetDefaultBuildOptions(builder.TypeBinary)
+	if _, err := applyBuildFlags(c, &opts); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	info := builder.CollectBuildInfo(ctx)
+	opts.BuildInfo = &info
+
+	filter := builder.MainFilter{
+		Include: c.StringSlice("include"),
+		Exclude: c.StringSlice("exclude"),
+	}
+	results, err := b.BuildMains(ctx, opts, c.String("cmd-dir"), filter, c.Int("parallel"))
+	if results == nil && err != nil {
+		return err
+	}
+	return printBuildResults(c, results, err)
+}
+
+// printBuildResults 输出多个目标的构建汇总，全部成功时为归档文件生成校验和清单
+func printBuildResults(c *cli.Context, results []*builder.BuildResult, buildErr error) error {
+	fmt.Println()
+	fmt.Println(builder.FormatBuildMatrix(results))
+
+	if buildErr != nil {
+		return buildErr
+	}
+
+	var archives []string
+	for _, result := range results {
+		if result.ArchivePath != "" {
+			archives = append(archives, result.ArchivePath)
+		}
+	}
+	if len(archives) > 0 {
+		checksums, err := builder.WriteChecksums(c.String("output"), archives)
+		if err != nil {
+			return err
+		}
+		fmt.Println("校验和清单:", checksums)
+	}
+
 	return nil
 }
 
+// applyBuildFlags 将命令行中指定的参数应用到构建选项，未指定的参数保持原值
+//
+// 指定了 --os 或 --arch 时返回true，此时构建配置中的目标平台列表不再生效。
+func applyBuildFlags(c *cli.Context, opts *builder.BuildOptions) (bool, error) {
+	if c.IsSet("output") {
+		opts.OutputPath = c.String("output")
+	}
+	if c.String("name") != "" {
+		opts.Name = c.String("name")
+	}
+	if c.String("version") != "" {
+		opts.Version = c.String("version")
+	}
+	if c.IsSet("main") {
+		opts.MainFile = c.String("main")
+	}
+	if c.IsSet("tags") {
+		opts.Tags = builder.ParseTags(c.String("tags"))
+	}
+	if c.IsSet("ldflags") {
+		ldflags, err := builder.ParseLDFlags(c.String("ldflags"))
+		if err != nil {
+			return false, err
+		}
+		opts.LDFlags = opts.LDFlags.Merge(ldflags)
+	}
+	if c.IsSet("gcflags") {
+		opts.GCFlags = c.String("gcflags")
+	}
+	if c.IsSet("buildmode") {
+		opts.BuildMode = c.String("buildmode")
+	}
+	opts.Debug = c.Bool("debug")
+	opts.CleanBuild = c.Bool("clean")
+	opts.Compress = c.Bool("compress")
+	opts.NoCache = c.Bool("no-cache")
+
+	if c.Bool("static") {
+		opts.LDFlags.Static = true
+	}
+	if c.Bool("trimpath") {
+		opts.TrimPath = true
+	}
+	if c.Bool("race") {
+		opts.Race = true
+	}
+
+	platformSet := c.IsSet("os") || c.IsSet("arch")
+	if platformSet {
+		opts.GoOS = c.String("os")
+		opts.GoArch = c.String("arch")
+	}
+	return platformSet, nil
+}
+
 func buildImageAction(c *cli.Context) error {
 	// 初始化配置
 	if err := config.Init(""); err != nil {
@@ -128,6 +312,26 @@ func buildImageAction(c *cli.Context) error {
 
 	opts.CleanBuild = c.Bool("no-cache")
 
+	// 多平台构建、推送和构建缓存
+	opts.Platforms = c.StringSlice("platform")
+	opts.Push = c.Bool("push")
+	opts.Target = c.String("target")
+	if c.String("cache-from") != "" {
+		opts.CacheFrom = []string{c.String("cache-from")}
+	}
+	if c.String("cache-to") != "" {
+		opts.CacheTo = []string{c.String("cache-to")}
+	}
+	opts.DockerBuilder = c.String("builder")
+
+	var err error
+	if opts.BuildArgs, err = parseKeyValues(c.StringSlice("build-arg"), "build-arg"); err != nil {
+		return err
+	}
+	if opts.Labels, err = parseKeyValues(c.StringSlice("label"), "label"); err != nil {
+		return err
+	}
+
 	// 执行构建
 	ctx := context.Background()
 	result, err := b.BuildDocker(ctx, opts)
@@ -140,3 +344,19 @@ func buildImageAction(c *cli.Context) error {
 
 	return nil
 }
+
+// parseKeyValues 解析 KEY=VALUE 形式的参数列表
+func parseKeyValues(values []string, flag string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(values))
+	for _, kv := range values {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("--%s 格式应为 KEY=VALUE: %q", flag, kv)
+		}
+		result[k] = v
+	}
+	return result, nil
+}
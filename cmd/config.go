@@ -121,6 +121,17 @@ func configShowAction(c *cli.Context) error {
 	fmt.Printf("docker.registry=%s\n", cfg.Docker.Registry)
 	fmt.Printf("docker.namespace=%s\n", cfg.Docker.Namespace)
 
+	// 打印迁移配置
+	fmt.Printf("migrate.lock_timeout=%d\n", cfg.Migrate.LockTimeout)
+	fmt.Printf("migrate.allow_out_of_order=%t\n", cfg.Migrate.AllowOutOfOrder)
+
+	// 打印构建配置
+	fmt.Printf("build.version_package=%s\n", cfg.Build.VersionPackage)
+	for name, profile := range cfg.Build.Profiles {
+		fmt.Printf("build.profiles.%s.main=%s\n", name, profile.Main)
+		fmt.Printf("build.profiles.%s.targets=%v\n", name, profile.Targets)
+	}
+
 	// 打印路径配置
 	for k, v := range cfg.Paths {
 		fmt.Printf("paths.%s=%s\n", k, v)
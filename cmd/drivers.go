@@ -0,0 +1,8 @@
+package cmd
+
+// 迁移命令使用的数据库驱动，迁移引擎本身不导入驱动，嵌入 pkg/migrate 的服务只需导入自己使用的驱动
+import (
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
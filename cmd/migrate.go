@@ -2,8 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/parker/ParkerCli/internal/config"
 	"github.com/parker/ParkerCli/internal/migrator"
+	"github.com/parker/ParkerCli/internal/migrator/targets"
 	"github.com/parker/ParkerCli/pkg/logger"
 	"github.com/urfave/cli/v2"
 )
@@ -17,6 +21,11 @@ var MigrateCommand = &cli.Command{
 			Usage: "升级数据库到最新版本",
 			Flags: []cli.Flag{
 				&cli.IntFlag{Name: "step", Value: 0, Usage: "迁移步数，0表示全部"},
+				outOfOrderFlag,
+				lockTimeoutFlag,
+				dryRunFlag,
+				sqlFileFlag,
+				schemaFileFlag,
 			},
 			Action: migrateUpAction,
 		},
@@ -25,12 +34,21 @@ var MigrateCommand = &cli.Command{
 			Usage: "回退到上一个数据库版本",
 			Flags: []cli.Flag{
 				&cli.IntFlag{Name: "step", Value: 1, Usage: "回退步数"},
+				lockTimeoutFlag,
+				dryRunFlag,
+				sqlFileFlag,
+				schemaFileFlag,
 			},
 			Action: migrateDownAction,
 		},
 		{
-			Name:   "status",
-			Usage:  "查看当前数据库迁移状态",
+			Name:  "status",
+			Usage: "查看当前数据库迁移状态",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "all", Usage: "查看所有已配置数据库的迁移状态"},
+				&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: "table", Usage: "输出格式 (table、json 或 yaml)"},
+				&cli.IntFlag{Name: "pending-exit-code", Value: 0, Usage: "存在待执行迁移时的退出状态码，0表示不检查"},
+			},
 			Action: migrateStatusAction,
 		},
 		{
@@ -44,20 +62,208 @@ var MigrateCommand = &cli.Command{
 		},
 		{
 			Name:   "reset",
-			Usage:  "重置所有迁移（先回退后升级）",
+			Usage:  "按批次倒序回滚所有已应用的迁移",
+			Flags:  []cli.Flag{lockTimeoutFlag, dryRunFlag, sqlFileFlag, schemaFileFlag},
 			Action: migrateResetAction,
 		},
 		{
 			Name:   "refresh",
-			Usage:  "刷新所有迁移（与reset相同）",
+			Usage:  "回滚所有已应用的迁移后重新应用全部迁移",
+			Flags:  []cli.Flag{lockTimeoutFlag, dryRunFlag, sqlFileFlag, schemaFileFlag},
 			Action: migrateRefreshAction,
 		},
+		{
+			Name:  "fresh",
+			Usage: "删除数据库中的所有表后重新应用全部迁移",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "yes", Aliases: []string{"y"}, Usage: "跳过确认提示"},
+				&cli.BoolFlag{Name: "force", Usage: "允许在生产环境(environment: production)中执行"},
+				lockTimeoutFlag,
+				schemaFileFlag,
+			},
+			Action: migrateFreshAction,
+		},
+		{
+			Name:      "goto",
+			Usage:     "迁移到指定版本（回滚之后的迁移，应用之前的迁移）",
+			ArgsUsage: "<version>",
+			Flags:     []cli.Flag{outOfOrderFlag, lockTimeoutFlag, dryRunFlag, sqlFileFlag, schemaFileFlag},
+			Action:    migrateGotoAction,
+		},
+		{
+			Name:   "redo",
+			Usage:  "回滚并重新应用最后一个批次的迁移",
+			Flags:  []cli.Flag{lockTimeoutFlag, dryRunFlag, sqlFileFlag, schemaFileFlag},
+			Action: migrateRedoAction,
+		},
+		{
+			Name:      "baseline",
+			Usage:     "将指定版本及之前的迁移标记为已应用而不执行，用于接入已有数据库",
+			ArgsUsage: "<version>",
+			Flags:     []cli.Flag{lockTimeoutFlag, dryRunFlag, sqlFileFlag},
+			Action:    migrateBaselineAction,
+		},
+		{
+			Name:  "squash",
+			Usage: "将指定ID及之前的迁移合并为一个基于当前数据库结构的基线迁移",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "until", Required: true, Usage: "合并到的迁移ID(包含)"},
+				lockTimeoutFlag,
+			},
+			Action: migrateSquashAction,
+		},
+		{
+			Name:  "diff",
+			Usage: "比较目标结构文件与数据库结构，生成包含变更及其反向语句的新迁移",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "schema", Required: true, Usage: "描述目标结构的SQL文件"},
+				&cli.StringFlag{Name: "name", Required: true, Usage: "新迁移的名称"},
+				&cli.BoolFlag{Name: "from-migrations", Usage: "与按现有迁移重建的数据库比较，而不是当前数据库"},
+				&cli.StringFlag{Name: "shadow-db", Usage: "用于执行目标结构的影子数据库名称(databases 中配置，会被清空)，SQLite可省略"},
+				&cli.BoolFlag{Name: "dry-run", Usage: "只输出生成的迁移内容，不创建文件"},
+			},
+			Action: migrateDiffAction,
+		},
+		{
+			Name:  "seed",
+			Usage: "种子数据管理",
+			Subcommands: []*cli.Command{
+				{
+					Name:  "create",
+					Usage: "创建新的种子数据文件",
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "name", Required: true, Usage: "种子名称"},
+						&cli.StringSliceFlag{Name: "env", Usage: "允许运行的环境，可指定多次，不指定表示生产环境以外的所有环境"},
+					},
+					Action: migrateSeedCreateAction,
+				},
+				{
+					Name:  "run",
+					Usage: "执行当前环境中尚未执行的种子数据",
+					Flags: []cli.Flag{
+						seedEnvFlag,
+						lockTimeoutFlag,
+					},
+					Action: migrateSeedRunAction,
+				},
+				{
+					Name:   "status",
+					Usage:  "查看种子数据执行状态",
+					Flags:  []cli.Flag{seedEnvFlag},
+					Action: migrateSeedStatusAction,
+				},
+			},
+		},
+		{
+			Name:  "lint",
+			Usage: "检查待执行迁移中的高风险操作（删除表/列、重命名、NOT NULL 列等）",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "all", Usage: "检查所有迁移文件，不连接数据库"},
+				&cli.StringFlag{Name: "format", Value: "text", Usage: "输出格式 (text 或 json)"},
+				&cli.StringFlag{Name: "fail-on", Value: "error", Usage: "以非零状态退出的最低严重程度 (error、warning 或 none)"},
+				&cli.IntFlag{Name: "exit-code", Value: 1, Usage: "发现问题时的退出状态码"},
+			},
+			Action: migrateLintAction,
+		},
+		{
+			Name:   "validate",
+			Usage:  "校验已应用迁移的文件是否被修改或删除，存在问题时以非零状态退出",
+			Action: migrateValidateAction,
+		},
+		{
+			Name:   "unlock",
+			Usage:  "清除残留的迁移锁",
+			Action: migrateUnlockAction,
+		},
 	},
 }
 
+// 等待迁移锁的超时时间，未设置时使用配置中的 migrate.lock_timeout
+var lockTimeoutFlag = &cli.DurationFlag{Name: "lock-timeout", Usage: "等待迁移锁的超时时间，如 30s"}
+
+// 允许应用早于最新已应用迁移的待执行迁移，未设置时使用配置中的 migrate.allow_out_of_order
+var outOfOrderFlag = &cli.BoolFlag{Name: "allow-out-of-order", Usage: "允许应用早于最新已应用迁移的待执行迁移"}
+
+// dry-run 模式只输出将要执行的SQL，不修改数据库
+var (
+	dryRunFlag  = &cli.BoolFlag{Name: "dry-run", Usage: "只打印将要执行的SQL，不修改数据库"}
+	sqlFileFlag = &cli.StringFlag{Name: "sql-file", Usage: "将dry-run的SQL写入指定文件(隐含 --dry-run)"}
+)
+
+// 迁移后写入数据库结构快照的文件，未设置时使用配置中的 paths.schema(默认不写入)
+var schemaFileFlag = &cli.StringFlag{Name: "schema", Usage: "迁移后将数据库结构快照写入指定文件"}
+
+// 运行种子数据的环境，未设置时使用配置中的 environment
+var seedEnvFlag = &cli.StringFlag{Name: "env", Usage: "运行环境，默认使用配置中的 environment"}
+
+// 目标数据库，对应配置中 databases 下的名称
+var dbFlag = &cli.StringFlag{Name: "db", Usage: "目标数据库名称(databases 中的配置)，默认使用 database 配置"}
+
+func init() {
+	addDBFlag(MigrateCommand.Subcommands)
+}
+
+// 为所有迁移子命令添加 --db 参数
+func addDBFlag(commands []*cli.Command) {
+	for _, command := range commands {
+		if len(command.Subcommands) > 0 {
+			addDBFlag(command.Subcommands)
+			continue
+		}
+		command.Flags = append(command.Flags, dbFlag)
+	}
+}
+
 // 获取迁移器实例
-func getMigrator() *migrator.StandardMigrator {
-	return migrator.NewStandardMigrator()
+func getMigrator(c *cli.Context) (*migrator.StandardMigrator, error) {
+	// 初始化配置
+	if err := config.Init(""); err != nil {
+		return nil, fmt.Errorf("初始化配置失败: %w", err)
+	}
+
+	return targets.New(c.String("db"))
+}
+
+// 应用命令行中的迁移锁超时设置
+func applyLockTimeout(c *cli.Context, m *migrator.StandardMigrator) {
+	if c.IsSet("lock-timeout") {
+		m.SetLockTimeout(c.Duration("lock-timeout"))
+	}
+}
+
+// 应用命令行中的结构快照文件设置
+func applySchemaFile(c *cli.Context, m *migrator.StandardMigrator) {
+	if c.IsSet("schema") {
+		m.SetSchemaFile(c.String("schema"))
+	}
+}
+
+// 应用命令行中的乱序迁移设置
+func applyOutOfOrder(c *cli.Context, m *migrator.StandardMigrator) {
+	if c.IsSet("allow-out-of-order") {
+		m.SetAllowOutOfOrder(c.Bool("allow-out-of-order"))
+	}
+}
+
+// 应用命令行中的dry-run设置，返回的函数用于关闭输出文件
+func applyDryRun(c *cli.Context, m *migrator.StandardMigrator) (func(), error) {
+	sqlFile := c.String("sql-file")
+	if sqlFile != "" {
+		f, err := os.Create(sqlFile)
+		if err != nil {
+			return nil, fmt.Errorf("创建SQL文件失败: %w", err)
+		}
+		m.SetDryRun(f)
+		return func() {
+			f.Close()
+			logger.Info("迁移计划已写入: %s", sqlFile)
+		}, nil
+	}
+
+	if c.Bool("dry-run") {
+		m.SetDryRun(os.Stdout)
+	}
+	return func() {}, nil
 }
 
 func migrateUpAction(c *cli.Context) error {
@@ -65,7 +271,21 @@ func migrateUpAction(c *cli.Context) error {
 
 	logger.Info("执行数据库升级，步数: %d", steps)
 
-	m := getMigrator()
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+	applySchemaFile(c, m)
+	applyOutOfOrder(c, m)
+
+	closeDryRun, err := applyDryRun(c, m)
+	if err != nil {
+		return err
+	}
+	defer closeDryRun()
+
 	if err := m.Up(steps); err != nil {
 		logger.Error("数据库升级失败: %v", err)
 		return err
@@ -80,7 +300,20 @@ func migrateDownAction(c *cli.Context) error {
 
 	logger.Info("执行数据库回退，步数: %d", steps)
 
-	m := getMigrator()
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+	applySchemaFile(c, m)
+
+	closeDryRun, err := applyDryRun(c, m)
+	if err != nil {
+		return err
+	}
+	defer closeDryRun()
+
 	if err := m.Down(steps); err != nil {
 		logger.Error("数据库回退失败: %v", err)
 		return err
@@ -91,9 +324,21 @@ func migrateDownAction(c *cli.Context) error {
 }
 
 func migrateStatusAction(c *cli.Context) error {
+	output := c.String("output")
+	machineOutput(output)
+
 	logger.Info("查看数据库迁移状态")
 
-	m := getMigrator()
+	if c.Bool("all") {
+		return migrateStatusAllAction(c)
+	}
+
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
 	migrations, err := m.Status()
 	if err != nil {
 		logger.Error("获取迁移状态失败: %v", err)
@@ -101,11 +346,69 @@ func migrateStatusAction(c *cli.Context) error {
 	}
 
 	// 显示格式化的迁移状态
-	fmt.Println(migrator.FormatMigrationStatus(migrations))
+	formatted, err := migrator.FormatMigrationStatusAs(migrations, output)
+	if err != nil {
+		return err
+	}
+	fmt.Println(formatted)
 
+	return pendingExit(c, migrator.HasPending(migrations))
+}
+
+// 查看所有已配置数据库的迁移状态
+func migrateStatusAllAction(c *cli.Context) error {
+	if err := config.Init(""); err != nil {
+		return fmt.Errorf("初始化配置失败: %w", err)
+	}
+
+	var statuses []migrator.TargetStatus
+	failed := 0
+	pending := false
+	for _, name := range targets.Names() {
+		status := migrator.TargetStatus{Target: name}
+
+		m, err := targets.New(name)
+		if err == nil {
+			status.Migrations, err = m.Status()
+			m.Close()
+		}
+		if err != nil {
+			status.Err = err
+			failed++
+		}
+		pending = pending || migrator.HasPending(status.Migrations)
+
+		statuses = append(statuses, status)
+	}
+
+	formatted, err := migrator.FormatTargetStatusAs(statuses, c.String("output"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(formatted)
+
+	if failed > 0 {
+		return fmt.Errorf("%d 个数据库获取迁移状态失败", failed)
+	}
+	return pendingExit(c, pending)
+}
+
+// 存在待执行迁移且设置了 --pending-exit-code 时以指定状态码退出，用于部署前检查
+func pendingExit(c *cli.Context, pending bool) error {
+	code := c.Int("pending-exit-code")
+	if pending && code != 0 {
+		return cli.Exit("存在待执行的迁移", code)
+	}
 	return nil
 }
 
+// 输出JSON/YAML时日志写入标准错误，保证标准输出可以直接解析
+func machineOutput(format string) {
+	if format == migrator.OutputJSON || format == migrator.OutputYAML {
+		logger.SetOutput(os.Stderr)
+	}
+}
+
 func migrateCreateAction(c *cli.Context) error {
 	name := c.String("name")
 	typeStr := c.String("type")
@@ -123,7 +426,12 @@ func migrateCreateAction(c *cli.Context) error {
 
 	logger.Info("创建新迁移: %s, 类型: %s", name, typeStr)
 
-	m := getMigrator()
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
 	migration, err := m.Create(name, migrationType)
 	if err != nil {
 		logger.Error("创建迁移失败: %v", err)
@@ -139,7 +447,20 @@ func migrateCreateAction(c *cli.Context) error {
 func migrateResetAction(c *cli.Context) error {
 	logger.Info("重置所有迁移")
 
-	m := getMigrator()
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+	applySchemaFile(c, m)
+
+	closeDryRun, err := applyDryRun(c, m)
+	if err != nil {
+		return err
+	}
+	defer closeDryRun()
+
 	if err := m.Reset(); err != nil {
 		logger.Error("重置迁移失败: %v", err)
 		return err
@@ -152,7 +473,20 @@ func migrateResetAction(c *cli.Context) error {
 func migrateRefreshAction(c *cli.Context) error {
 	logger.Info("刷新所有迁移")
 
-	m := getMigrator()
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+	applySchemaFile(c, m)
+
+	closeDryRun, err := applyDryRun(c, m)
+	if err != nil {
+		return err
+	}
+	defer closeDryRun()
+
 	if err := m.Refresh(); err != nil {
 		logger.Error("刷新迁移失败: %v", err)
 		return err
@@ -161,3 +495,321 @@ func migrateRefreshAction(c *cli.Context) error {
 	logger.Info("刷新迁移完成")
 	return nil
 }
+
+func migrateFreshAction(c *cli.Context) error {
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+	applySchemaFile(c, m)
+
+	env := config.GetString("environment")
+	if env == "production" && !c.Bool("force") {
+		return fmt.Errorf("当前为生产环境，拒绝删除所有表；如确需执行请使用 --force")
+	}
+
+	if !c.Bool("yes") {
+		fmt.Printf("警告: 将删除数据库中的所有表并重新应用迁移 (环境: %s)\n", env)
+		fmt.Print("是否继续? (y/N): ")
+		var confirm string
+		fmt.Scanln(&confirm)
+		if strings.ToLower(confirm) != "y" {
+			return fmt.Errorf("操作被用户取消")
+		}
+	}
+
+	if err := m.Fresh(); err != nil {
+		logger.Error("重建数据库失败: %v", err)
+		return err
+	}
+
+	logger.Info("重建数据库完成")
+	return nil
+}
+
+func migrateGotoAction(c *cli.Context) error {
+	version := c.Args().First()
+	if version == "" {
+		return fmt.Errorf("必须提供目标版本")
+	}
+
+	logger.Info("迁移到版本: %s", version)
+
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+	applySchemaFile(c, m)
+	applyOutOfOrder(c, m)
+
+	closeDryRun, err := applyDryRun(c, m)
+	if err != nil {
+		return err
+	}
+	defer closeDryRun()
+
+	if err := m.Goto(version); err != nil {
+		logger.Error("迁移到版本 %s 失败: %v", version, err)
+		return err
+	}
+
+	logger.Info("已迁移到版本: %s", version)
+	return nil
+}
+
+func migrateRedoAction(c *cli.Context) error {
+	logger.Info("重新执行最后一个批次的迁移")
+
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+	applySchemaFile(c, m)
+
+	closeDryRun, err := applyDryRun(c, m)
+	if err != nil {
+		return err
+	}
+	defer closeDryRun()
+
+	if err := m.Redo(); err != nil {
+		logger.Error("重新执行迁移失败: %v", err)
+		return err
+	}
+
+	logger.Info("重新执行迁移完成")
+	return nil
+}
+
+func migrateBaselineAction(c *cli.Context) error {
+	version := c.Args().First()
+	if version == "" {
+		return fmt.Errorf("必须提供基线版本")
+	}
+
+	logger.Info("设置迁移基线: %s", version)
+
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+
+	closeDryRun, err := applyDryRun(c, m)
+	if err != nil {
+		return err
+	}
+	defer closeDryRun()
+
+	if err := m.Baseline(version); err != nil {
+		logger.Error("设置迁移基线失败: %v", err)
+		return err
+	}
+
+	logger.Info("迁移基线设置完成")
+	return nil
+}
+
+func migrateSquashAction(c *cli.Context) error {
+	until := c.String("until")
+
+	logger.Info("合并迁移，截止: %s", until)
+
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+
+	migration, err := m.Squash(until)
+	if err != nil {
+		logger.Error("合并迁移失败: %v", err)
+		return err
+	}
+
+	logger.Info("合并迁移完成: %s", migration.FilePath)
+	return nil
+}
+
+func migrateDiffAction(c *cli.Context) error {
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if c.Bool("dry-run") {
+		m.SetDryRun(os.Stdout)
+	}
+
+	opts := migrator.DiffOptions{
+		SchemaFile:     c.String("schema"),
+		Name:           c.String("name"),
+		FromMigrations: c.Bool("from-migrations"),
+	}
+	if name := c.String("shadow-db"); name != "" {
+		shadow, err := targets.New(name)
+		if err != nil {
+			return fmt.Errorf("加载影子数据库配置失败: %w", err)
+		}
+		defer shadow.Close()
+		opts.Shadow = shadow
+	}
+
+	migration, err := m.Diff(opts)
+	if err != nil {
+		logger.Error("生成结构差异迁移失败: %v", err)
+		return err
+	}
+
+	if migration != nil {
+		logger.Info("已生成迁移: %s", migration.FilePath)
+	}
+	return nil
+}
+
+func migrateSeedCreateAction(c *cli.Context) error {
+	name := c.String("name")
+
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	seed, err := m.CreateSeed(name, c.StringSlice("env"))
+	if err != nil {
+		logger.Error("创建种子失败: %v", err)
+		return err
+	}
+
+	logger.Info("创建种子成功: %s (ID: %s)", seed.Name, seed.ID)
+	return nil
+}
+
+func migrateSeedRunAction(c *cli.Context) error {
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	applyLockTimeout(c, m)
+
+	if c.IsSet("env") {
+		m.SetEnvironment(c.String("env"))
+	}
+
+	if _, err := m.RunSeeds(); err != nil {
+		logger.Error("执行种子数据失败: %v", err)
+		return err
+	}
+
+	logger.Info("种子数据执行完成")
+	return nil
+}
+
+func migrateSeedStatusAction(c *cli.Context) error {
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if c.IsSet("env") {
+		m.SetEnvironment(c.String("env"))
+	}
+
+	seeds, err := m.SeedStatus()
+	if err != nil {
+		logger.Error("获取种子状态失败: %v", err)
+		return err
+	}
+
+	fmt.Println(migrator.FormatSeedStatus(seeds))
+	return nil
+}
+
+func migrateLintAction(c *cli.Context) error {
+	failOn := c.String("fail-on")
+	if failOn != "error" && failOn != "warning" && failOn != "none" {
+		return fmt.Errorf("不支持的严重程度: %s", failOn)
+	}
+
+	machineOutput(c.String("format"))
+
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	issues, err := m.Lint(c.Bool("all"))
+	if err != nil {
+		logger.Error("检查迁移失败: %v", err)
+		return err
+	}
+
+	output, err := migrator.FormatLintIssues(issues, c.String("format"))
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+
+	if failOn != "none" && migrator.HasLintSeverity(issues, migrator.LintSeverity(failOn)) {
+		return cli.Exit("", c.Int("exit-code"))
+	}
+	return nil
+}
+
+func migrateUnlockAction(c *cli.Context) error {
+	logger.Info("清除迁移锁")
+
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Unlock(); err != nil {
+		logger.Error("清除迁移锁失败: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func migrateValidateAction(c *cli.Context) error {
+	m, err := getMigrator(c)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	problems, err := m.Validate()
+	if err != nil {
+		logger.Error("校验迁移失败: %v", err)
+		return err
+	}
+
+	if len(problems) == 0 {
+		fmt.Println("所有已应用迁移的文件均未被修改")
+		return nil
+	}
+
+	for _, migration := range problems {
+		if migration.Status == migrator.StatusMissing {
+			fmt.Printf("已删除: %s (%s)\n", migration.ID, migration.Name)
+		} else {
+			fmt.Printf("已修改: %s (%s) %s\n", migration.ID, migration.Name, migration.FilePath)
+		}
+	}
+
+	return cli.Exit(fmt.Sprintf("发现 %d 个迁移与数据库记录不一致", len(problems)), 1)
+}
@@ -1,14 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
-	"time"
 
+	"github.com/parker/ParkerCli/internal/builder"
+	"github.com/parker/ParkerCli/internal/config"
 	"github.com/urfave/cli/v2"
 )
 
@@ -24,7 +26,9 @@ var ReleaseCommand = &cli.Command{
 				&cli.StringSliceFlag{Name: "os", Value: cli.NewStringSlice("linux", "darwin", "windows"), Usage: "目标系统"},
 				&cli.StringSliceFlag{Name: "arch", Value: cli.NewStringSlice("amd64", "arm64"), Usage: "目标架构"},
 				&cli.StringFlag{Name: "output", Value: "./dist", Usage: "输出目录"},
-				&cli.BoolFlag{Name: "compress", Usage: "是否压缩二进制文件"},
+				&cli.BoolFlag{Name: "compress", Usage: "将二进制与README、LICENSE打包为tar.gz(Windows为zip)"},
+				&cli.BoolFlag{Name: "no-cache", Usage: "不使用构建缓存"},
+				&cli.IntFlag{Name: "parallel", Aliases: []string{"p"}, Value: runtime.NumCPU(), Usage: "同时构建的目标数量"},
 			},
 			Action: releaseBuildAction,
 		},
@@ -50,77 +54,63 @@ func releaseBuildAction(c *cli.Context) error {
 	outputDir := c.String("output")
 	compress := c.Bool("compress")
 
-	fmt.Printf("构建发行版 v%s\n", version)
-
-	// 创建输出目录
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
+	// 初始化配置
+	if err := config.Init(""); err != nil {
+		return fmt.Errorf("初始化配置失败: %w", err)
 	}
 
-	// 设置版本信息和构建时间的ldflags
-	buildTime := time.Now().Format("2006-01-02T15:04:05")
-	ldflags := fmt.Sprintf("-X 'main.Version=%s' -X 'main.BuildTime=%s'", version, buildTime)
+	fmt.Printf("构建发行版 v%s\n", version)
+
+	// 所有目标注入同一份git提交和构建时间信息
+	ctx := context.Background()
+	info := builder.CollectBuildInfo(ctx)
+	versionVars := builder.ConfiguredVersionVars()
 
-	// 对每个OS/Arch组合构建二进制
+	// 每个OS/Arch组合对应一个构建目标，二进制输出到输出目录: ParkerCli-{version}-{os}-{arch}；
+	// 打包时二进制放在各自的子目录中，归档文件统一输出到输出目录: ParkerCli_{version}_{os}_{arch}.tar.gz
+	var builds []builder.BuildOptions
 	for _, goos := range targetOSList {
 		for _, goarch := range targetArchList {
-			fmt.Printf("构建 %s/%s...\n", goos, goarch)
-
-			// 构建输出文件名
-			outputName := fmt.Sprintf("ParkerCli-%s-%s-%s", version, goos, goarch)
-			if goos == "windows" {
-				outputName += ".exe"
+			binaryDir := outputDir
+			name := fmt.Sprintf("ParkerCli-%s-%s-%s", version, goos, goarch)
+			if compress {
+				binaryDir = filepath.Join(outputDir, goos+"_"+goarch)
+				name = "ParkerCli"
 			}
-			outputPath := filepath.Join(outputDir, outputName)
-
-			// 构建命令
-			cmd := exec.Command("go", "build", "-ldflags", ldflags, "-trimpath", "-o", outputPath)
-
-			// 设置环境变量
-			env := append(os.Environ(),
-				fmt.Sprintf("GOOS=%s", goos),
-				fmt.Sprintf("GOARCH=%s", goarch),
-				"CGO_ENABLED=0")
-			cmd.Env = env
+			builds = append(builds, builder.BuildOptions{
+				Type:        builder.TypeBinary,
+				OutputPath:  binaryDir,
+				Name:        name,
+				Version:     version,
+				GoOS:        goos,
+				GoArch:      goarch,
+				TrimPath:    true,
+				LDFlags:     builder.LDFlags{Strip: true},
+				Env:         []string{"CGO_ENABLED=0"},
+				Compress:    compress,
+				ArchiveDir:  outputDir,
+				VersionVars: versionVars,
+				BuildInfo:   &info,
+				NoCache:     c.Bool("no-cache"),
+			})
+		}
+	}
 
-			// 执行构建
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
+	b := builder.NewStandardBuilder()
+	results, buildErr := b.BuildBinaries(ctx, builds, c.Int("parallel"))
 
-			if err := cmd.Run(); err != nil {
-				fmt.Printf("构建 %s/%s 失败: %v\n", goos, goarch, err)
-				continue
-			}
+	fmt.Println()
+	fmt.Println(builder.FormatBuildMatrix(results))
 
-			// 如果需要压缩
-			if compress {
-				fmt.Printf("压缩 %s...\n", outputName)
-
-				// 可以使用如upx等工具压缩二进制
-				if runtime.GOOS == "windows" {
-					// Windows下可能需要额外的工具
-					fmt.Println("警告: 在Windows下跳过压缩")
-				} else {
-					// 检查是否安装了upx
-					_, err := exec.LookPath("upx")
-					if err == nil {
-						// 使用upx压缩
-						upxCmd := exec.Command("upx", "--best", outputPath)
-						upxCmd.Stdout = os.Stdout
-						upxCmd.Stderr = os.Stderr
-
-						if err := upxCmd.Run(); err != nil {
-							fmt.Printf("压缩失败: %v\n", err)
-						}
-					} else {
-						fmt.Println("未找到upx，跳过压缩步骤")
-					}
-				}
-			}
+	if buildErr != nil {
+		return buildErr
+	}
 
-			fmt.Printf("成功构建: %s\n", outputPath)
-		}
+	checksums, err := builder.WriteChecksums(outputDir, builder.Artifacts(results))
+	if err != nil {
+		return err
 	}
+	fmt.Println("校验和清单:", checksums)
 
 	fmt.Println("所有平台构建完成，输出目录:", outputDir)
 	return nil
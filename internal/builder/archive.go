@@ -0,0 +1,221 @@
+package builder
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/parker/ParkerCli/internal/utils"
+)
+
+// ChecksumsFile 发行包校验和清单的文件名
+const ChecksumsFile = "checksums.txt"
+
+// archiveFilePatterns 默认随二进制一起打包的文件
+var archiveFilePatterns = []string{"README*", "LICENSE*"}
+
+// archiveName 返回归档文件名: {name}_{version}_{os}_{arch}.tar.gz，Windows使用.zip
+func archiveName(opts BuildOptions) string {
+	parts := []string{opts.Name}
+	if opts.Version != "" {
+		parts = append(parts, opts.Version)
+	}
+	parts = append(parts, opts.GoOS, opts.GoArch)
+
+	ext := ".tar.gz"
+	if opts.GoOS == "windows" {
+		ext = ".zip"
+	}
+	return strings.Join(parts, "_") + ext
+}
+
+// defaultArchiveFiles 查找当前目录下的README和LICENSE文件
+func defaultArchiveFiles() []string {
+	var files []string
+	for _, pattern := range archiveFilePatterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, match := range matches {
+			if utils.FileExists(match) {
+				files = append(files, match)
+			}
+		}
+	}
+	return files
+}
+
+// createArchive 将二进制和附带文件打包，根据扩展名选择zip或tar.gz格式
+//
+// 所有文件都放在归档的根目录下。
+func createArchive(archivePath, binaryPath string, files []string) error {
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("创建归档文件失败: %w", err)
+	}
+
+	entries := append([]string{binaryPath}, files...)
+	if strings.HasSuffix(archivePath, ".zip") {
+		err = writeZip(f, entries)
+	} else {
+		err = writeTarGz(f, entries)
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(archivePath)
+		return fmt.Errorf("写入归档文件失败: %w", err)
+	}
+	return nil
+}
+
+// writeTarGz 写入tar.gz归档
+func writeTarGz(w io.Writer, entries []string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, entry := range entries {
+		if err := addTarEntry(tw, entry); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// addTarEntry 向tar归档添加单个文件
+func addTarEntry(tw *tar.Writer, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.Base(path)
+	// 不记录本机的用户信息
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+// writeZip 写入zip归档
+func writeZip(w io.Writer, entries []string) error {
+	zw := zip.NewWriter(w)
+
+	for _, entry := range entries {
+		if err := addZipEntry(zw, entry); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// addZipEntry 向zip归档添加单个文件
+func addZipEntry(zw *zip.Writer, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.Base(path)
+	header.Method = zip.Deflate
+
+	dst, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// WriteChecksums 在目录中写入文件的SHA-256校验和清单，格式与 sha256sum 的输出一致
+func WriteChecksums(dir string, files []string) (string, error) {
+	names := make([]string, len(files))
+	copy(names, files)
+	sort.Slice(names, func(i, j int) bool {
+		return filepath.Base(names[i]) < filepath.Base(names[j])
+	})
+
+	var b strings.Builder
+	for _, file := range names {
+		sum, err := fileSHA256(file)
+		if err != nil {
+			return "", fmt.Errorf("计算 %s 的校验和失败: %w", file, err)
+		}
+		b.WriteString(fmt.Sprintf("%s  %s\n", sum, filepath.Base(file)))
+	}
+
+	path := filepath.Join(dir, ChecksumsFile)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", fmt.Errorf("写入校验和文件失败: %w", err)
+	}
+	return path, nil
+}
+
+// fileSHA256 计算文件的SHA-256
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Artifacts 返回构建结果中需要发布的文件：有归档时为归档文件，否则为二进制
+func Artifacts(results []*BuildResult) []string {
+	var files []string
+	for _, result := range results {
+		if !result.Success {
+			continue
+		}
+		if result.ArchivePath != "" {
+			files = append(files, result.ArchivePath)
+		} else {
+			files = append(files, result.OutputPath)
+		}
+	}
+	return files
+}
@@ -3,11 +3,11 @@ package builder
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
 	"time"
 
@@ -28,21 +28,43 @@ const (
 
 // BuildOptions 构建选项
 type BuildOptions struct {
-	Type        BuildType // 构建类型
-	OutputPath  string    // 输出路径
-	Name        string    // 名称
-	Version     string    // 版本
-	MainFile    string    // 主文件
-	GoOS        string    // 目标操作系统
-	GoArch      string    // 目标架构
-	LDFlags     string    // 链接标志
-	Tags        string    // 构建标签
-	Dockerfile  string    // Dockerfile路径
-	DockerImage string    // Docker镜像名称
-	DockerTags  []string  // Docker标签
-	Debug       bool      // 调试模式
-	Compress    bool      // 是否压缩
-	CleanBuild  bool      // 是否清理构建
+	Type             BuildType         // 构建类型
+	OutputPath       string            // 输出路径
+	Name             string            // 名称
+	Version          string            // 版本
+	MainFile         string            // 主文件
+	GoOS             string            // 目标操作系统
+	GoArch           string            // 目标架构
+	Tags             []string          // 构建标签
+	LDFlags          LDFlags           // 链接参数
+	GCFlags          string            // 编译参数，如 all=-N -l
+	BuildMode        string            // 构建模式，如 pie、c-shared，为空时使用默认模式
+	Race             bool              // 是否启用竞态检测
+	Dockerfile       string            // Dockerfile路径
+	DockerImage      string            // Docker镜像名称
+	DockerTags       []string          // Docker标签
+	Platforms        []string          // 镜像目标平台，如 linux/amd64，指定后使用 buildx 构建
+	Push             bool              // 构建后推送到镜像仓库
+	BuildArgs        map[string]string // 镜像构建参数(--build-arg)
+	Target           string            // 多阶段构建的目标阶段
+	Labels           map[string]string // 额外的镜像标签，覆盖自动生成的OCI标签
+	CacheFrom        []string          // 镜像构建缓存来源，如 type=registry,ref=registry/app:cache
+	CacheTo          []string          // 镜像构建缓存导出位置
+	DockerBuilder    string            // buildx 构建器名称，为空时使用当前构建器
+	Registry         string            // 镜像仓库地址，推送前使用下面的账号登录
+	RegistryUser     string            // 镜像仓库用户名，为空时不登录
+	RegistryPassword string            // 镜像仓库密码
+	Debug            bool              // 调试模式，保留符号表和调试信息
+	Compress         bool              // 是否将二进制打包为tar.gz(Windows为zip)
+	ArchiveDir       string            // 归档文件输出目录，为空时与二进制相同
+	ArchiveFiles     []string          // 随二进制一起打包的文件，为空时使用当前目录下的README和LICENSE
+	CleanBuild       bool              // 是否清理构建
+	TrimPath         bool              // 是否移除二进制中的本地路径
+	Env              []string          // 额外的环境变量，如 CGO_ENABLED=0
+	VersionVars      VersionVars       // 接收构建信息的变量路径，为空时使用 main 包中的变量
+	BuildInfo        *BuildInfo        // 要注入的构建信息，为空时构建前自动收集
+	NoCache          bool              // 不使用构建缓存
+	CacheDir         string            // 构建缓存目录，为空时使用用户缓存目录
 }
 
 // BuildResult 构建结果
@@ -55,12 +77,24 @@ type BuildResult struct {
 	ImageID      string    // Docker镜像ID
 	ImageSize    int64     // Docker镜像大小
 	ErrorMessage string    // 错误信息
+	Name         string    // 二进制名称
+	GoOS         string    // 目标操作系统
+	GoArch       string    // 目标架构
+	ArchivePath  string    // 归档文件路径
+	CacheHit     bool      // 是否从构建缓存恢复
+	Relinked     bool      // 编译输入未变化，只使用新的构建信息重新链接
+
+	// 注入二进制的构建时间(RFC3339)。构建时间不参与缓存键，
+	// 其他构建信息未变化时从缓存恢复的二进制保留首次构建时注入的时间，与 BuildTime 不同
+	StampedBuildTime string
 }
 
 // Builder 构建器接口
 type Builder interface {
 	BuildBinary(ctx context.Context, opts BuildOptions) (*BuildResult, error)
 	BuildDocker(ctx context.Context, opts BuildOptions) (*BuildResult, error)
+	BuildBinaries(ctx context.Context, builds []BuildOptions, parallel int) ([]*BuildResult, error)
+	BuildMains(ctx context.Context, opts BuildOptions, dir string, filter MainFilter, parallel int) ([]*BuildResult, error)
 }
 
 // StandardBuilder 标准构建器实现
@@ -76,6 +110,8 @@ func (b *StandardBuilder) BuildBinary(ctx context.Context, opts BuildOptions) (*
 	startTime := time.Now()
 	result := &BuildResult{
 		BuildTime: startTime,
+		GoOS:      opts.GoOS,
+		GoArch:    opts.GoArch,
 	}
 
 	// 如果未指定输出目录则使用默认目录
@@ -96,9 +132,10 @@ func (b *StandardBuilder) BuildBinary(ctx context.Context, opts BuildOptions) (*
 		}
 		opts.Name = filepath.Base(cwd)
 	}
+	result.Name = opts.Name
 
-	// 确定可执行文件名称（Windows添加.exe后缀）
-	exeName := utils.ExecutableName(opts.Name)
+	// 确定可执行文件名称（目标系统为Windows时添加.exe后缀）
+	exeName := executableName(opts.Name, opts.GoOS)
 	outputFile := filepath.Join(opts.OutputPath, exeName)
 
 	// 如果启用了清理构建，删除现有文件
@@ -108,31 +145,15 @@ func (b *StandardBuilder) BuildBinary(ctx context.Context, opts BuildOptions) (*
 		}
 	}
 
-	// 构建命令
-	args := []string{"build"}
-
-	// 添加构建标签
-	if opts.Tags != "" {
-		args = append(args, "-tags", opts.Tags)
-	}
-
-	// 添加LDFlags（如版本信息）
-	ldflags := opts.LDFlags
-	if opts.Version != "" && !strings.Contains(ldflags, "main.Version") {
-		if ldflags != "" {
-			ldflags += " "
-		}
-		ldflags += fmt.Sprintf("-X 'main.Version=%s'", opts.Version)
-	}
-
-	if ldflags != "" {
-		args = append(args, "-ldflags", ldflags)
+	// 注入版本、git提交等构建信息，调试模式下保留调试信息
+	ldflags, stableLDFlags, stampedTime := buildInfoLDFlags(ctx, opts)
+	result.StampedBuildTime = stampedTime
+	if opts.Debug {
+		ldflags.Strip = false
 	}
 
-	// 设置输出文件
-	args = append(args, "-o", outputFile)
-
-	// 设置主文件路径
+	// 构建命令
+	args := append(buildArgs(opts, ldflags), "-o", outputFile)
 	if opts.MainFile != "" {
 		args = append(args, opts.MainFile)
 	}
@@ -145,28 +166,70 @@ func (b *StandardBuilder) BuildBinary(ctx context.Context, opts BuildOptions) (*
 	if opts.GoArch != "" {
 		env = append(env, "GOARCH="+opts.GoArch)
 	}
+	env = append(env, opts.Env...)
 
-	// 如果是调试模式，不剔除调试信息
 	if opts.Debug {
 		env = append(env, "GODEBUG=gctrace=1")
-	} else if !strings.Contains(ldflags, "-s") && !strings.Contains(ldflags, "-w") {
-		args[2] += " -s -w" // 剔除调试信息减小体积
 	}
 
-	// 创建命令
-	cmd := exec.CommandContext(ctx, "go", args...)
-	cmd.Env = env
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	// 编译输入没有变化时使用缓存，清理构建时不使用缓存
+	var cache *buildCache
+	var cacheKey string
+	if !opts.NoCache && !opts.CleanBuild {
+		// -X 注入的变量只影响链接，不参与缓存键，新的提交或版本号不会使缓存失效
+		keyFlags := ldflags
+		keyFlags.Vars = nil
+		keyArgs := buildArgs(opts, keyFlags)
+		if opts.MainFile != "" {
+			keyArgs = append(keyArgs, opts.MainFile)
+		}
+		cache, cacheKey = b.lookupCache(ctx, opts, keyArgs, env)
+		if cache != nil {
+			if entry, ok := cache.Lookup(cacheKey); ok {
+				// 构建时间每次都不同，只比较其他注入的变量
+				if maps.Equal(entry.Vars, stableLDFlags.Vars) {
+					if err := cache.Restore(cacheKey, outputFile); err != nil {
+						logger.Warn("%v", err)
+					} else {
+						logger.Info("构建输入未变化，从缓存恢复: %s (构建时间: %s)", outputFile, entry.BuildTime)
+						result.CacheHit = true
+						result.StampedBuildTime = entry.BuildTime
+					}
+				} else {
+					// 编译结果由Go的构建缓存复用，只需使用新的构建信息重新链接
+					logger.Info("编译输入未变化，使用新的构建信息重新链接: %s", outputFile)
+					result.Relinked = true
+				}
+			}
+		}
+	}
 
-	// 执行构建
-	logger.Info("开始构建二进制: %s", outputFile)
-	logger.Debug("构建命令: go %s", strings.Join(args, " "))
+	if !result.CacheHit {
+		// 创建命令
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Env = env
 
-	if err := cmd.Run(); err != nil {
-		result.Success = false
-		result.ErrorMessage = err.Error()
-		return result, fmt.Errorf("构建失败: %w", err)
+		// 执行构建
+		logger.Info("开始构建二进制: %s", outputFile)
+		logger.Debug("构建命令: go %s", strings.Join(args, " "))
+
+		// 收集编译输出，并行构建时各目标的错误信息不会混在一起
+		if output, err := cmd.CombinedOutput(); err != nil {
+			result.Success = false
+			result.Duration = time.Since(startTime).Seconds()
+			result.ErrorMessage = strings.TrimSpace(string(output))
+			if result.ErrorMessage == "" {
+				result.ErrorMessage = err.Error()
+			}
+			return result, fmt.Errorf("构建失败: %w\n%s", err, result.ErrorMessage)
+		}
+
+		if cache != nil {
+			entry := cacheEntry{BuildTime: stampedTime, Vars: stableLDFlags.Vars}
+			if err := cache.Store(cacheKey, outputFile, entry); err != nil {
+				logger.Warn("%v", err)
+			}
+		}
 	}
 
 	// 检查输出文件是否存在
@@ -184,7 +247,17 @@ func (b *StandardBuilder) BuildBinary(ctx context.Context, opts BuildOptions) (*
 		result.Size = fileInfo.Size()
 	}
 
-	// 如果需要压缩，可以在此添加压缩逻辑
+	// 将二进制与README、LICENSE打包
+	if opts.Compress {
+		archivePath, err := archiveBinary(opts, outputFile)
+		if err != nil {
+			result.Success = false
+			result.Duration = time.Since(startTime).Seconds()
+			result.ErrorMessage = err.Error()
+			return result, err
+		}
+		result.ArchivePath = archivePath
+	}
 
 	// 计算构建时间
 	duration := time.Since(startTime)
@@ -198,130 +271,110 @@ func (b *StandardBuilder) BuildBinary(ctx context.Context, opts BuildOptions) (*
 	return result, nil
 }
 
-// BuildDocker 构建Docker镜像
-func (b *StandardBuilder) BuildDocker(ctx context.Context, opts BuildOptions) (*BuildResult, error) {
-	startTime := time.Now()
-	result := &BuildResult{
-		BuildTime: startTime,
+// lookupCache 计算构建的缓存键，无法使用缓存时返回nil
+func (b *StandardBuilder) lookupCache(ctx context.Context, opts BuildOptions, args, env []string) (*buildCache, string) {
+	cache, err := newBuildCache(opts.CacheDir)
+	if err != nil {
+		logger.Warn("构建缓存不可用: %v", err)
+		return nil, ""
 	}
 
-	// 检查docker命令是否可用
-	if err := exec.Command("docker", "--version").Run(); err != nil {
-		return result, fmt.Errorf("docker命令不可用，请确保已安装Docker: %w", err)
+	key, err := cache.Key(ctx, opts, args, env)
+	if err != nil {
+		logger.Warn("计算构建缓存键失败，跳过缓存: %v", err)
+		return nil, ""
 	}
+	logger.Debug("构建缓存键: %s", key)
+	return cache, key
+}
 
-	// 设置默认Dockerfile路径
-	if opts.Dockerfile == "" {
-		opts.Dockerfile = "Dockerfile"
+// buildArgs 根据构建选项生成 go build 的参数，不含输出路径和主包
+func buildArgs(opts BuildOptions, ldflags LDFlags) []string {
+	args := []string{"build"}
+	if len(opts.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(opts.Tags, ","))
 	}
-
-	// 确保Dockerfile存在
-	if !utils.FileExists(opts.Dockerfile) {
-		return result, fmt.Errorf("Dockerfile不存在: %s", opts.Dockerfile)
+	if opts.Race {
+		args = append(args, "-race")
 	}
-
-	// 获取镜像名称
-	imageName := opts.DockerImage
-	if imageName == "" {
-		// 使用配置的namespace和应用名称
-		cfg := config.GetAll()
-		namespace := cfg.Docker.Namespace
-		if namespace == "" {
-			namespace = "myapp"
-		}
-		imageName = fmt.Sprintf("%s/%s", namespace, opts.Name)
+	if opts.TrimPath {
+		args = append(args, "-trimpath")
 	}
-
-	// 添加标签
-	tags := opts.DockerTags
-	if len(tags) == 0 {
-		if opts.Version != "" {
-			tags = append(tags, opts.Version)
-		}
-		tags = append(tags, "latest")
+	if opts.BuildMode != "" {
+		args = append(args, "-buildmode", opts.BuildMode)
 	}
-
-	// 构建Docker命令
-	args := []string{"build"}
-
-	// 添加标签
-	for _, tag := range tags {
-		args = append(args, "-t", fmt.Sprintf("%s:%s", imageName, tag))
+	if opts.GCFlags != "" {
+		args = append(args, "-gcflags", opts.GCFlags)
 	}
-
-	// 添加构建参数
-	if opts.Version != "" {
-		args = append(args, "--build-arg", fmt.Sprintf("VERSION=%s", opts.Version))
+	if flags := ldflags.String(); flags != "" {
+		args = append(args, "-ldflags", flags)
 	}
+	return args
+}
 
-	// 是否启用缓存
-	if opts.CleanBuild {
-		args = append(args, "--no-cache")
+// buildInfoLDFlags 返回合并了构建信息的链接参数，用户在 LDFlags.Vars 中指定的变量优先
+//
+// 第二个返回值不含构建时间，用于判断缓存的二进制注入的变量是否变化，第三个返回值为注入的构建时间。
+func buildInfoLDFlags(ctx context.Context, opts BuildOptions) (LDFlags, LDFlags, string) {
+	// 复制一份，并行构建的多个目标可能共享同一个BuildInfo
+	var info BuildInfo
+	if opts.BuildInfo != nil {
+		info = *opts.BuildInfo
+	} else {
+		info = CollectBuildInfo(ctx)
 	}
+	info.Version = opts.Version
 
-	// 指定Dockerfile路径
-	args = append(args, "-f", opts.Dockerfile)
-
-	// 当前目录作为构建上下文
-	args = append(args, ".")
-
-	// 创建Docker构建命令
-	cmd := exec.CommandContext(ctx, "docker", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	vars := opts.VersionVars
+	if vars == (VersionVars{}) {
+		vars = DefaultVersionVars("main")
+	}
 
-	// 执行构建
-	logger.Info("开始构建Docker镜像: %s", imageName)
-	logger.Debug("构建命令: docker %s", strings.Join(args, " "))
+	stable := info
+	stable.BuildTime = ""
+	return info.LDFlags(vars).Merge(opts.LDFlags), stable.LDFlags(vars).Merge(opts.LDFlags), info.BuildTime
+}
 
-	if err := cmd.Run(); err != nil {
-		result.Success = false
-		result.ErrorMessage = err.Error()
-		return result, fmt.Errorf("Docker构建失败: %w", err)
+// archiveBinary 打包构建好的二进制，返回归档文件路径
+func archiveBinary(opts BuildOptions, binaryPath string) (string, error) {
+	dir := opts.ArchiveDir
+	if dir == "" {
+		dir = opts.OutputPath
 	}
-
-	// 获取镜像ID
-	imageIDCmd := exec.Command("docker", "images", "-q", fmt.Sprintf("%s:%s", imageName, tags[0]))
-	imageIDBytes, err := imageIDCmd.Output()
-	if err != nil {
-		logger.Warn("获取镜像ID失败: %v", err)
-	} else {
-		result.ImageID = strings.TrimSpace(string(imageIDBytes))
+	if err := utils.EnsureDir(dir); err != nil {
+		return "", fmt.Errorf("创建归档目录失败: %w", err)
 	}
 
-	// 获取镜像大小
-	if result.ImageID != "" {
-		inspectCmd := exec.Command("docker", "image", "inspect", "-f", "{{.Size}}", result.ImageID)
-		sizeBytes, err := inspectCmd.Output()
-		if err != nil {
-			logger.Warn("获取镜像大小失败: %v", err)
-		} else {
-			size, err := strconv.ParseInt(strings.TrimSpace(string(sizeBytes)), 10, 64)
-			if err == nil {
-				result.ImageSize = size
-			}
-		}
+	files := opts.ArchiveFiles
+	if len(files) == 0 {
+		files = defaultArchiveFiles()
 	}
 
-	// 计算构建时间
-	duration := time.Since(startTime)
-	result.Duration = duration.Seconds()
-	result.Success = true
-	result.OutputPath = imageName
-
-	// 打印构建结果
-	var tagsStr string
-	for i, tag := range tags {
-		if i > 0 {
-			tagsStr += ", "
-		}
-		tagsStr += tag
+	// 未指定目标平台时归档名称使用本机平台
+	if opts.GoOS == "" {
+		opts.GoOS = runtime.GOOS
+	}
+	if opts.GoArch == "" {
+		opts.GoArch = runtime.GOARCH
 	}
 
-	logger.Info("Docker构建成功: %s (%s) (大小: %s, 耗时: %.2f秒)",
-		imageName, tagsStr, utils.BytesToHumanReadable(result.ImageSize), result.Duration)
+	archivePath := filepath.Join(dir, archiveName(opts))
+	logger.Info("打包: %s", archivePath)
+	if err := createArchive(archivePath, binaryPath, files); err != nil {
+		return "", err
+	}
+	return archivePath, nil
+}
 
-	return result, nil
+// executableName 根据目标操作系统确定可执行文件名称
+func executableName(name, goos string) string {
+	if goos == "" {
+		return utils.ExecutableName(name)
+	}
+	if goos == "windows" && !strings.HasSuffix(name, ".exe") {
+		return name + ".exe"
+	}
+	return name
 }
 
 // GetDefaultBuildOptions 获取默认构建选项
@@ -335,12 +388,14 @@ func GetDefaultBuildOptions(buildType BuildType) BuildOptions {
 		CleanBuild: false,
 		Compress:   false,
 		Debug:      false,
+		LDFlags:    LDFlags{Strip: true}, // 默认剔除调试信息减小体积
 	}
 
 	// 从配置获取应用名称和版本
 	cfg := config.GetAll()
 	opts.Name = cfg.AppName
 	opts.Version = cfg.Version
+	opts.VersionVars = ConfiguredVersionVars()
 
 	// 如果是Docker构建
 	if buildType == TypeDocker {
@@ -353,6 +408,9 @@ func GetDefaultBuildOptions(buildType BuildType) BuildOptions {
 			opts.DockerImage = fmt.Sprintf("%s/%s", cfg.Docker.Namespace, opts.Name)
 		}
 		opts.DockerTags = []string{opts.Version, "latest"}
+		opts.Registry = cfg.Docker.Registry
+		opts.RegistryUser = cfg.Docker.Username
+		opts.RegistryPassword = cfg.Docker.Password
 	}
 
 	return opts
@@ -372,6 +430,18 @@ func FormatBuildResult(result *BuildResult, buildType BuildType) string {
 	if buildType == TypeBinary {
 		builder.WriteString(fmt.Sprintf("输出文件: %s\n", result.OutputPath))
 		builder.WriteString(fmt.Sprintf("文件大小: %s\n", utils.BytesToHumanReadable(result.Size)))
+		if result.ArchivePath != "" {
+			builder.WriteString(fmt.Sprintf("归档文件: %s\n", result.ArchivePath))
+		}
+		if result.Relinked {
+			builder.WriteString("构建缓存: 命中，已重新链接\n")
+		}
+		if result.CacheHit {
+			builder.WriteString("构建缓存: 命中\n")
+			if result.StampedBuildTime != "" {
+				builder.WriteString(fmt.Sprintf("注入的构建时间: %s\n", result.StampedBuildTime))
+			}
+		}
 	} else {
 		builder.WriteString(fmt.Sprintf("镜像名称: %s\n", result.OutputPath))
 		builder.WriteString(fmt.Sprintf("镜像ID: %s\n", result.ImageID))
@@ -0,0 +1,723 @@
+package builder
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parker/ParkerCli/internal/config"
+)
+
+// 测试并行构建多个目标，单个目标失败不影响其他目标
+func TestBuildBinaries(t *testing.T) {
+	dir := t.TempDir()
+	builds := []BuildOptions{
+		{OutputPath: dir, Name: "hello-linux", Version: "1.0.0", MainFile: "./testdata/hello", GoOS: "linux", GoArch: "amd64", NoCache: true},
+		{OutputPath: dir, Name: "hello-windows", Version: "1.0.0", MainFile: "./testdata/hello", GoOS: "windows", GoArch: "amd64", NoCache: true},
+		{OutputPath: dir, Name: "hello-bogus", Version: "1.0.0", MainFile: "./testdata/hello", GoOS: "linux", GoArch: "bogus", NoCache: true},
+	}
+
+	b := NewStandardBuilder()
+	results, err := b.BuildBinaries(context.Background(), builds, 2)
+	if err == nil {
+		t.Fatal("存在失败目标时应返回错误")
+	}
+	if !strings.Contains(err.Error(), "hello-bogus") {
+		t.Errorf("错误信息应包含失败的目标: %v", err)
+	}
+
+	if len(results) != len(builds) {
+		t.Fatalf("结果数量为 %d，期望 %d", len(results), len(builds))
+	}
+	if !results[0].Success || results[0].Size == 0 {
+		t.Errorf("linux/amd64 应构建成功: %+v", results[0])
+	}
+	if !strings.HasSuffix(results[1].OutputPath, "hello-windows.exe") {
+		t.Errorf("Windows目标应添加.exe后缀: %s", results[1].OutputPath)
+	}
+	if results[2].Success || results[2].ErrorMessage == "" {
+		t.Errorf("linux/bogus 应构建失败并记录错误信息: %+v", results[2])
+	}
+
+	summary := FormatBuildMatrix(results)
+	if !strings.Contains(summary, "成功 2/3") || !strings.Contains(summary, "linux/bogus") {
+		t.Errorf("构建汇总不正确:\n%s", summary)
+	}
+}
+
+// 测试打包二进制并生成校验和清单
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "app")
+	readme := filepath.Join(dir, "README.md")
+	if err := os.WriteFile(binary, []byte("binary"), 0755); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	if err := os.WriteFile(readme, []byte("说明"), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		goos string
+		want string
+	}{
+		{"Linux Tar Gz", "linux", "app_1.0.0_linux_amd64.tar.gz"},
+		{"Windows Zip", "windows", "app_1.0.0_windows_amd64.zip"},
+	}
+
+	var archives []string
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := BuildOptions{Name: "app", Version: "1.0.0", GoOS: tt.goos, GoArch: "amd64", OutputPath: dir, ArchiveFiles: []string{readme}}
+			path, err := archiveBinary(opts, binary)
+			if err != nil {
+				t.Fatalf("打包失败: %v", err)
+			}
+			if filepath.Base(path) != tt.want {
+				t.Errorf("归档文件名为 %s，期望 %s", filepath.Base(path), tt.want)
+			}
+			archives = append(archives, path)
+
+			entries := archiveEntries(t, path)
+			if strings.Join(entries, ",") != "app,README.md" {
+				t.Errorf("归档内容为 %v", entries)
+			}
+		})
+	}
+
+	checksums, err := WriteChecksums(dir, archives)
+	if err != nil {
+		t.Fatalf("生成校验和失败: %v", err)
+	}
+	content, err := os.ReadFile(checksums)
+	if err != nil {
+		t.Fatalf("读取校验和文件失败: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("校验和清单应有2行:\n%s", content)
+	}
+	for i, want := range []string{"app_1.0.0_linux_amd64.tar.gz", "app_1.0.0_windows_amd64.zip"} {
+		fields := strings.Fields(lines[i])
+		if len(fields) != 2 || len(fields[0]) != 64 || fields[1] != want {
+			t.Errorf("第%d行格式不正确: %s", i+1, lines[i])
+		}
+	}
+}
+
+// archiveEntries 读取归档中的文件名
+func archiveEntries(t *testing.T, path string) []string {
+	var names []string
+
+	if strings.HasSuffix(path, ".zip") {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatalf("打开zip失败: %v", err)
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("打开归档失败: %v", err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("读取gzip失败: %v", err)
+	}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("读取tar失败: %v", err)
+		}
+		names = append(names, header.Name)
+	}
+	return names
+}
+
+// 测试生成注入构建信息的 -X 变量
+func TestBuildInfoLDFlags(t *testing.T) {
+	info := BuildInfo{
+		Version:   "1.2.0",
+		Commit:    "3f2c1a9b",
+		Dirty:     true,
+		Branch:    "main",
+		BuildTime: "2024-01-02T15:04:05Z",
+		GoVersion: "go1.22.2",
+		BuildUser: "ci",
+	}
+	vars := DefaultVersionVars("example.com/app/internal/version")
+
+	tests := []struct {
+		name    string
+		info    BuildInfo
+		vars    VersionVars
+		want    map[string]string
+		notWant []string
+	}{
+		{
+			name: "All Fields",
+			info: info,
+			vars: vars,
+			want: map[string]string{
+				"example.com/app/internal/version.Version":   "1.2.0",
+				"example.com/app/internal/version.Commit":    "3f2c1a9b",
+				"example.com/app/internal/version.Dirty":     "true",
+				"example.com/app/internal/version.Branch":    "main",
+				"example.com/app/internal/version.BuildUser": "ci",
+			},
+		},
+		{
+			name:    "Without Git",
+			info:    BuildInfo{Version: "1.2.0", BuildTime: "2024-01-02T15:04:05Z"},
+			vars:    vars,
+			notWant: []string{"example.com/app/internal/version.Commit", "example.com/app/internal/version.Dirty", "example.com/app/internal/version.Branch"},
+		},
+		{
+			name:    "Disabled Variable",
+			info:    info,
+			vars:    VersionVars{Version: "main.Version"},
+			want:    map[string]string{"main.Version": "1.2.0"},
+			notWant: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.info.LDFlags(tt.vars).Vars
+			for path, want := range tt.want {
+				if got[path] != want {
+					t.Errorf("%s 为 %q，期望 %q", path, got[path], want)
+				}
+			}
+			for _, path := range tt.notWant {
+				if _, ok := got[path]; ok {
+					t.Errorf("不应包含 %s: %v", path, got)
+				}
+			}
+		})
+	}
+
+	// 用户指定的变量优先于自动注入的构建信息
+	opts := BuildOptions{
+		Version:     "1.2.0",
+		VersionVars: VersionVars{Version: "main.Version", BuildTime: "main.BuildTime"},
+		BuildInfo:   &BuildInfo{BuildTime: "2024-01-02T15:04:05Z"},
+		LDFlags:     LDFlags{Vars: map[string]string{"main.Version": "custom"}},
+	}
+	ldflags, stable, stamped := buildInfoLDFlags(context.Background(), opts)
+	if ldflags.Vars["main.Version"] != "custom" || ldflags.Vars["main.BuildTime"] == "" {
+		t.Errorf("合并后的变量不正确: %v", ldflags.Vars)
+	}
+	if _, ok := stable.Vars["main.BuildTime"]; ok {
+		t.Errorf("计算缓存键的ldflags不应包含构建时间: %v", stable.Vars)
+	}
+	if stamped != "2024-01-02T15:04:05Z" {
+		t.Errorf("注入的构建时间为 %q", stamped)
+	}
+}
+
+// 测试解析 -ldflags 字符串
+func TestParseLDFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    LDFlags
+		wantErr bool
+	}{
+		{name: "Empty", input: "", want: LDFlags{}},
+		{name: "Strip", input: "-s -w", want: LDFlags{Strip: true}},
+		{name: "Only W", input: "-w", want: LDFlags{Extra: []string{"-w"}}},
+		{
+			name:  "Vars",
+			input: `-X main.Version=1.0 -X 'main.Name=my app' -X=main.Mode=prod`,
+			want:  LDFlags{Vars: map[string]string{"main.Version": "1.0", "main.Name": "my app", "main.Mode": "prod"}},
+		},
+		{
+			name:  "Static",
+			input: `-linkmode external -extldflags "-static -lm"`,
+			want:  LDFlags{Static: true, ExtLDFlags: []string{"-lm"}, Extra: []string{"-linkmode", "external"}},
+		},
+		{name: "Missing Value", input: "-X", wantErr: true},
+		{name: "Bad Var", input: "-X main.Version", wantErr: true},
+		{name: "Unterminated Quote", input: "-X 'main.Version=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLDFlags(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误为 %v，期望返回错误: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.String() != tt.want.String() {
+				t.Errorf("解析结果为 %q，期望 %q", got.String(), tt.want.String())
+			}
+		})
+	}
+}
+
+// 测试由构建选项生成 go build 参数
+func TestBuildArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts BuildOptions
+		want []string
+	}{
+		{
+			name: "Default",
+			opts: BuildOptions{},
+			want: []string{"build"},
+		},
+		{
+			name: "Tags With Strip",
+			opts: BuildOptions{Tags: []string{"prod", "netgo"}, LDFlags: LDFlags{Strip: true}},
+			want: []string{"build", "-tags", "prod,netgo", "-ldflags", "-s -w"},
+		},
+		{
+			name: "Vars Sorted And Quoted",
+			opts: BuildOptions{LDFlags: LDFlags{Vars: map[string]string{"main.Version": "1.0", "main.Name": "my app"}}},
+			want: []string{"build", "-ldflags", "-X 'main.Name=my app' -X main.Version=1.0"},
+		},
+		{
+			name: "Static",
+			opts: BuildOptions{LDFlags: LDFlags{Strip: true, Static: true}},
+			want: []string{"build", "-ldflags", "-s -w -extldflags -static"},
+		},
+		{
+			name: "Extldflags",
+			opts: BuildOptions{LDFlags: LDFlags{ExtLDFlags: []string{"-L/opt/my lib", "-lm"}, Static: true, Extra: []string{"-linkmode", "external"}}},
+			want: []string{"build", "-ldflags", `-extldflags "'-L/opt/my lib' -lm -static" -linkmode external`},
+		},
+		{
+			name: "All Options",
+			opts: BuildOptions{
+				Tags:      []string{"prod"},
+				Race:      true,
+				TrimPath:  true,
+				BuildMode: "pie",
+				GCFlags:   "all=-N -l",
+				LDFlags:   LDFlags{Strip: true, Vars: map[string]string{"main.Version": "1.0"}},
+			},
+			want: []string{"build", "-tags", "prod", "-race", "-trimpath", "-buildmode", "pie", "-gcflags", "all=-N -l", "-ldflags", "-s -w -X main.Version=1.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildArgs(tt.opts, tt.opts.LDFlags)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("参数为 %q，期望 %q", got, tt.want)
+			}
+
+			// 生成的ldflags能被原样解析回来
+			if flags := tt.opts.LDFlags.String(); flags != "" {
+				parsed, err := ParseLDFlags(flags)
+				if err != nil {
+					t.Fatalf("解析生成的ldflags失败: %v", err)
+				}
+				if parsed.String() != flags {
+					t.Errorf("解析后为 %q，期望 %q", parsed.String(), flags)
+				}
+			}
+		})
+	}
+}
+
+// 测试构建时自动注入版本和提交信息
+func TestBuildBinaryStampsBuildInfo(t *testing.T) {
+	dir := t.TempDir()
+	opts := BuildOptions{
+		OutputPath: dir,
+		Name:       "hello",
+		Version:    "1.2.0",
+		MainFile:   "./testdata/hello",
+		BuildInfo:  &BuildInfo{Commit: "3f2c1a9b"},
+		NoCache:    true,
+	}
+
+	result, err := NewStandardBuilder().BuildBinary(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("构建失败: %v", err)
+	}
+
+	out, err := exec.Command(result.OutputPath).Output()
+	if err != nil {
+		t.Fatalf("运行构建结果失败: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "1.2.0 3f2c1a9b" {
+		t.Errorf("注入的构建信息为 %q", got)
+	}
+}
+
+// 测试构建输入不变时从缓存恢复
+func TestBuildCache(t *testing.T) {
+	dir := t.TempDir()
+	base := BuildOptions{
+		OutputPath: filepath.Join(dir, "dist"),
+		Name:       "hello",
+		Version:    "1.2.0",
+		MainFile:   "./testdata/hello",
+		BuildInfo:  &BuildInfo{Commit: "3f2c1a9b", BuildTime: "2024-01-02T15:04:05Z"},
+		CacheDir:   filepath.Join(dir, "cache"),
+	}
+	b := NewStandardBuilder()
+
+	build := func(opts BuildOptions) *BuildResult {
+		t.Helper()
+		result, err := b.BuildBinary(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("构建失败: %v", err)
+		}
+		return result
+	}
+
+	if result := build(base); result.CacheHit || result.StampedBuildTime != "2024-01-02T15:04:05Z" {
+		t.Fatalf("首次构建不应命中缓存: %+v", result)
+	}
+
+	// 构建时间不参与缓存键，恢复的二进制保留首次构建的时间
+	rebuilt := base
+	rebuilt.BuildInfo = &BuildInfo{Commit: "3f2c1a9b", BuildTime: "2024-02-03T10:00:00Z"}
+	if result := build(rebuilt); !result.CacheHit {
+		t.Error("构建输入未变化时应命中缓存")
+	} else if result.StampedBuildTime != "2024-01-02T15:04:05Z" {
+		t.Errorf("恢复的二进制的构建时间应为首次构建的时间: %s", result.StampedBuildTime)
+	}
+
+	run := func() string {
+		t.Helper()
+		out, err := exec.Command(filepath.Join(base.OutputPath, "hello")).Output()
+		if err != nil {
+			t.Fatalf("运行构建结果失败: %v", err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	// 新的提交不影响缓存键，使用新的提交SHA重新链接
+	committed := base
+	committed.BuildInfo = &BuildInfo{Commit: "7d9e0b14", BuildTime: "2024-02-03T10:00:00Z"}
+	if result := build(committed); result.CacheHit || !result.Relinked {
+		t.Errorf("提交变化后应重新链接: %+v", result)
+	}
+	if got := run(); got != "1.2.0 7d9e0b14" {
+		t.Errorf("重新链接的二进制输出为 %q", got)
+	}
+	if result := build(committed); !result.CacheHit {
+		t.Error("重新链接后相同的构建应命中缓存")
+	}
+
+	changed := base
+	changed.Version = "1.3.0"
+	if result := build(changed); result.CacheHit || !result.Relinked {
+		t.Errorf("版本变化后应重新链接: %+v", result)
+	}
+	if got := run(); got != "1.3.0 3f2c1a9b" {
+		t.Errorf("重新链接的二进制输出为 %q", got)
+	}
+
+	// 编译参数变化后缓存失效
+	trimmed := base
+	trimmed.TrimPath = true
+	if result := build(trimmed); result.CacheHit || result.Relinked {
+		t.Errorf("编译参数变化后不应使用缓存: %+v", result)
+	}
+
+	cleaned := base
+	cleaned.CleanBuild = true
+	if result := build(cleaned); result.CacheHit {
+		t.Error("清理构建不应使用缓存")
+	}
+
+	if got := run(); got != "1.2.0 3f2c1a9b" {
+		t.Errorf("清理构建的二进制输出为 %q", got)
+	}
+}
+
+// 测试缓存键只包含目标平台参与构建的源文件
+func TestBuildCacheSourceFiles(t *testing.T) {
+	cache := &buildCache{dir: t.TempDir()}
+
+	tests := []struct {
+		goos    string
+		windows bool
+	}{
+		{"linux", false},
+		{"windows", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			opts := BuildOptions{MainFile: "./testdata/hello", GoOS: tt.goos, GoArch: "amd64"}
+			files, err := cache.sourceFiles(context.Background(), opts, append(os.Environ(), "GOOS="+tt.goos, "GOARCH=amd64"))
+			if err != nil {
+				t.Fatalf("列出源文件失败: %v", err)
+			}
+
+			var hasMain, hasWindows, hasGoMod bool
+			for _, file := range files {
+				switch filepath.Base(file) {
+				case "main.go":
+					hasMain = true
+				case "hello_windows.go":
+					hasWindows = true
+				case "go.mod":
+					hasGoMod = true
+				}
+			}
+			if !hasMain || !hasGoMod {
+				t.Errorf("源文件列表缺少 main.go 或 go.mod: %v", files)
+			}
+			if hasWindows != tt.windows {
+				t.Errorf("hello_windows.go 是否包含: %v，期望 %v", hasWindows, tt.windows)
+			}
+		})
+	}
+}
+
+// 测试并行写入同一个缓存键
+func TestBuildCacheStoreConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	cache := &buildCache{dir: filepath.Join(dir, "cache")}
+	key := strings.Repeat("ab", 32)
+
+	const workers = 8
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		src := filepath.Join(dir, fmt.Sprintf("bin%d", i))
+		if err := os.WriteFile(src, []byte(strings.Repeat("x", 1<<16)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			errs <- cache.Store(key, src, cacheEntry{BuildTime: "2024-01-02T15:04:05Z"})
+		}()
+	}
+	for i := 0; i < workers; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("写入缓存失败: %v", err)
+		}
+	}
+
+	if _, ok := cache.Lookup(key); !ok {
+		t.Fatal("写入后应能读取缓存条目")
+	}
+	data, err := os.ReadFile(cache.path(key))
+	if err != nil || len(data) != 1<<16 {
+		t.Errorf("缓存的二进制不完整: %d 字节, %v", len(data), err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(cache.path(key)))
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("残留临时文件: %s", entry.Name())
+		}
+	}
+}
+
+// 测试应用构建配置并展开目标平台
+func TestApplyProfile(t *testing.T) {
+	cgo := false
+	profile := config.BuildProfile{
+		Main:    "./cmd/api",
+		Output:  "api",
+		Tags:    "prod",
+		Env:     []string{"GOAMD64=v3"},
+		CGO:     &cgo,
+		Targets: []string{"linux/amd64", "linux/arm64"},
+	}
+
+	opts := BuildOptions{OutputPath: "dist", MainFile: "main.go", Name: "app"}
+	platforms, err := ApplyProfile(&opts, profile)
+	if err != nil {
+		t.Fatalf("应用构建配置失败: %v", err)
+	}
+	if opts.MainFile != "./cmd/api" || opts.Name != "api" || strings.Join(opts.Tags, ",") != "prod" {
+		t.Errorf("构建选项未更新: %+v", opts)
+	}
+	if strings.Join(opts.Env, " ") != "GOAMD64=v3 CGO_ENABLED=0" {
+		t.Errorf("环境变量为 %v", opts.Env)
+	}
+
+	builds := ExpandPlatforms(opts, platforms)
+	if len(builds) != 2 {
+		t.Fatalf("应展开为2个目标，实际 %d 个", len(builds))
+	}
+	for i, want := range []string{filepath.Join("dist", "linux_amd64"), filepath.Join("dist", "linux_arm64")} {
+		if builds[i].OutputPath != want || builds[i].ArchiveDir != "dist" {
+			t.Errorf("第%d个目标的输出目录为 %s，归档目录为 %s", i+1, builds[i].OutputPath, builds[i].ArchiveDir)
+		}
+	}
+
+	single := ExpandPlatforms(opts, platforms[:1])
+	if len(single) != 1 || single[0].OutputPath != "dist" || single[0].GoArch != "amd64" {
+		t.Errorf("单个目标不应使用子目录: %+v", single)
+	}
+
+	strip := false
+	unstripped := BuildOptions{LDFlags: LDFlags{Strip: true}}
+	if _, err := ApplyProfile(&unstripped, config.BuildProfile{LDFlags: "-X main.Mode=prod", Strip: &strip}); err != nil {
+		t.Fatalf("应用构建配置失败: %v", err)
+	}
+	if unstripped.LDFlags.Strip || unstripped.LDFlags.Vars["main.Mode"] != "prod" {
+		t.Errorf("构建配置应能关闭剔除调试信息: %+v", unstripped.LDFlags)
+	}
+
+	if _, err := ApplyProfile(&opts, config.BuildProfile{Targets: []string{"linux"}}); err == nil {
+		t.Error("目标平台格式不正确时应返回错误")
+	}
+}
+
+// 测试查找 cmd 下的 main 包并按筛选条件构建
+func TestBuildMains(t *testing.T) {
+	ctx := context.Background()
+	mains, err := DiscoverMains(ctx, "testdata/multi/cmd")
+	if err != nil {
+		t.Fatalf("查找main包失败: %v", err)
+	}
+	var names []string
+	for _, pkg := range mains {
+		names = append(names, pkg.Name+"="+pkg.Path)
+	}
+	if got := strings.Join(names, " "); got != "api=./testdata/multi/cmd/api worker=./testdata/multi/cmd/worker" {
+		t.Errorf("找到的main包为 %s", got)
+	}
+
+	tests := []struct {
+		name   string
+		filter MainFilter
+		want   string
+	}{
+		{"all", MainFilter{}, "api worker"},
+		{"include", MainFilter{Include: []string{"wor*"}}, "worker"},
+		{"exclude", MainFilter{Exclude: []string{"worker"}}, "api"},
+		{"exclude wins", MainFilter{Include: []string{"*"}, Exclude: []string{"api"}}, "worker"},
+		{"no match", MainFilter{Include: []string{"migrate"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := tt.filter.Filter(mains)
+			if err != nil {
+				t.Fatalf("筛选失败: %v", err)
+			}
+			var got []string
+			for _, pkg := range matched {
+				got = append(got, pkg.Name)
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("筛选结果为 %v，期望 %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (MainFilter{Include: []string{"["}}).Filter(mains); err == nil {
+		t.Error("筛选模式不正确时应返回错误")
+	}
+	if _, err := DiscoverMains(ctx, "testdata/hello/cmd"); err == nil {
+		t.Error("目录不存在时应返回错误")
+	}
+
+	dir := t.TempDir()
+	opts := BuildOptions{OutputPath: dir, Version: "1.0.0", NoCache: true}
+	results, err := NewStandardBuilder().BuildMains(ctx, opts, "testdata/multi/cmd", MainFilter{}, 2)
+	if err != nil {
+		t.Fatalf("构建失败: %v", err)
+	}
+	for i, name := range []string{"api", "worker"} {
+		if results[i].Name != name || !results[i].Success {
+			t.Fatalf("第%d个结果不正确: %+v", i+1, results[i])
+		}
+		out, err := exec.Command(results[i].OutputPath).Output()
+		if err != nil {
+			t.Fatalf("运行 %s 失败: %v", name, err)
+		}
+		if strings.TrimSpace(string(out)) != name {
+			t.Errorf("%s 输出为 %q", name, out)
+		}
+	}
+}
+
+// 测试生成镜像构建参数
+func TestDockerBuildArgs(t *testing.T) {
+	labels := imageLabels(
+		BuildOptions{Name: "app", Version: "1.2.0", Labels: map[string]string{"team": "backend"}},
+		BuildInfo{Commit: "3f2c1a9b", BuildTime: "2024-01-02T15:04:05Z"},
+	)
+	if labels[LabelRevision] != "3f2c1a9b" || labels[LabelVersion] != "1.2.0" || labels["team"] != "backend" {
+		t.Fatalf("镜像标签不正确: %v", labels)
+	}
+
+	tests := []struct {
+		name    string
+		opts    BuildOptions
+		labels  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Plain Build",
+			opts: BuildOptions{Dockerfile: "Dockerfile", Version: "1.2.0"},
+			want: "build -t app:1.2.0 --build-arg VERSION=1.2.0 -f Dockerfile .",
+		},
+		{
+			name:   "Build Args And Target",
+			opts:   BuildOptions{Dockerfile: "Dockerfile", Version: "1.2.0", BuildArgs: map[string]string{"VERSION": "custom", "GOPROXY": "direct"}, Target: "release"},
+			labels: map[string]string{LabelRevision: "3f2c1a9b"},
+			want:   "build -t app:1.2.0 --build-arg GOPROXY=direct --build-arg VERSION=custom --target release --label org.opencontainers.image.revision=3f2c1a9b -f Dockerfile .",
+		},
+		{
+			name: "Single Platform Load",
+			opts: BuildOptions{Dockerfile: "Dockerfile", Platforms: []string{"linux/arm64"}, CacheFrom: []string{"type=local,src=/tmp/cache"}},
+			want: "buildx build --platform linux/arm64 -t app:1.2.0 --cache-from type=local,src=/tmp/cache --load --metadata-file meta.json -f Dockerfile .",
+		},
+		{
+			name:   "Multi Platform Push",
+			opts:   BuildOptions{Dockerfile: "Dockerfile", Platforms: []string{"linux/amd64", "linux/arm64"}, Push: true, CacheTo: []string{"type=inline"}, CleanBuild: true},
+			labels: map[string]string{LabelVersion: "1.2.0", "team": "backend"},
+			want: "buildx build --platform linux/amd64,linux/arm64 -t app:1.2.0 " +
+				"--label org.opencontainers.image.version=1.2.0 --label team=backend " +
+				"--annotation index,manifest:org.opencontainers.image.version=1.2.0 " +
+				"--cache-to type=inline --no-cache --push --metadata-file meta.json -f Dockerfile .",
+		},
+		{
+			name:    "Multi Platform Without Push",
+			opts:    BuildOptions{Dockerfile: "Dockerfile", Platforms: []string{"linux/amd64", "linux/arm64"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadataFile := ""
+			if useBuildx(tt.opts) {
+				metadataFile = "meta.json"
+			}
+			args, err := dockerBuildArgs(tt.opts, "app", []string{"1.2.0"}, tt.labels, metadataFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误为 %v，期望返回错误: %v", err, tt.wantErr)
+			}
+			if got := strings.Join(args, " "); !tt.wantErr && got != tt.want {
+				t.Errorf("参数为:\n%s\n期望:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/parker/ParkerCli/internal/config"
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// BuildInfo 构建时注入到二进制中的信息
+type BuildInfo struct {
+	Version   string // 版本号
+	Commit    string // git提交SHA
+	Dirty     bool   // 工作区是否有未提交的修改
+	Branch    string // git分支
+	BuildTime string // 构建时间(RFC3339, UTC)
+	GoVersion string // 构建使用的Go版本
+	BuildUser string // 执行构建的用户
+}
+
+// VersionVars 接收构建信息的变量完整路径，为空的项不注入
+type VersionVars struct {
+	Version   string
+	Commit    string
+	Dirty     string
+	Branch    string
+	BuildTime string
+	GoVersion string
+	BuildUser string
+}
+
+// DefaultVersionVars 返回指定包中的默认变量路径，如 main.Version、main.Commit
+func DefaultVersionVars(pkg string) VersionVars {
+	return VersionVars{
+		Version:   pkg + ".Version",
+		Commit:    pkg + ".Commit",
+		Dirty:     pkg + ".Dirty",
+		Branch:    pkg + ".Branch",
+		BuildTime: pkg + ".BuildTime",
+		GoVersion: pkg + ".GoVersion",
+		BuildUser: pkg + ".BuildUser",
+	}
+}
+
+// ConfiguredVersionVars 根据配置中的 build.version_package 和 build.version_vars 确定变量路径
+func ConfiguredVersionVars() VersionVars {
+	cfg := config.GetAll().Build
+
+	pkg := cfg.VersionPackage
+	if pkg == "" {
+		pkg = "main"
+	}
+	vars := DefaultVersionVars(pkg)
+
+	overrides := map[string]*string{
+		"version":    &vars.Version,
+		"commit":     &vars.Commit,
+		"dirty":      &vars.Dirty,
+		"branch":     &vars.Branch,
+		"build_time": &vars.BuildTime,
+		"go_version": &vars.GoVersion,
+		"build_user": &vars.BuildUser,
+	}
+	for key, path := range cfg.VersionVars {
+		target, ok := overrides[key]
+		if !ok {
+			logger.Warn("未知的构建信息变量: %s", key)
+			continue
+		}
+		*target = path
+	}
+
+	return vars
+}
+
+// CollectBuildInfo 从git和本机环境收集构建信息
+//
+// 不在git仓库中或未安装git时，提交相关的信息留空。
+func CollectBuildInfo(ctx context.Context) BuildInfo {
+	info := BuildInfo{
+		BuildTime: time.Now().UTC().Format(time.RFC3339),
+		GoVersion: runtime.Version(),
+		BuildUser: buildUser(),
+	}
+
+	if out, err := commandOutput(ctx, "go", "env", "GOVERSION"); err == nil && out != "" {
+		info.GoVersion = out
+	}
+
+	commit, err := commandOutput(ctx, "git", "rev-parse", "HEAD")
+	if err != nil {
+		logger.Debug("读取git提交信息失败: %v", err)
+		return info
+	}
+	info.Commit = commit
+
+	if status, err := commandOutput(ctx, "git", "status", "--porcelain"); err == nil {
+		info.Dirty = status != ""
+	}
+	if branch, err := commandOutput(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		info.Branch = branch
+	}
+
+	return info
+}
+
+// LDFlags 生成注入构建信息的 -X 变量，未配置路径或没有值的项不注入
+func (i BuildInfo) LDFlags(vars VersionVars) LDFlags {
+	values := map[string]string{
+		vars.Version:   i.Version,
+		vars.Commit:    i.Commit,
+		vars.Branch:    i.Branch,
+		vars.BuildTime: i.BuildTime,
+		vars.GoVersion: i.GoVersion,
+		vars.BuildUser: i.BuildUser,
+	}
+	// 只有获取到提交信息时Dirty才有意义
+	if i.Commit != "" {
+		values[vars.Dirty] = fmt.Sprint(i.Dirty)
+	}
+
+	flags := LDFlags{Vars: make(map[string]string)}
+	for path, value := range values {
+		if path == "" || value == "" {
+			continue
+		}
+		flags.Vars[path] = value
+	}
+	return flags
+}
+
+// buildUser 返回当前用户名，CI环境中通常为运行构建的账号
+func buildUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// commandOutput 执行命令并返回去除首尾空白的输出
+func commandOutput(ctx context.Context, name string, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
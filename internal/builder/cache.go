@@ -0,0 +1,275 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/parker/ParkerCli/internal/utils"
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// sourceListTemplate 列出主模块中参与构建的文件，每行格式为 目录|文件|文件...
+const sourceListTemplate = `{{if or (eq .ImportPath "command-line-arguments") (and .Module .Module.Main)}}` +
+	`{{.Dir}}{{range .GoFiles}}|{{.}}{{end}}{{range .CgoFiles}}|{{.}}{{end}}` +
+	`{{range .CFiles}}|{{.}}{{end}}{{range .HFiles}}|{{.}}{{end}}{{range .EmbedFiles}}|{{.}}{{end}}{{end}}`
+
+// cacheEnvKeys 影响编译结果的环境变量
+var cacheEnvKeys = []string{"CGO_ENABLED", "GOFLAGS", "GOAMD64", "GOARM", "GOARM64", "GOEXPERIMENT", "CC"}
+
+// buildCache 以构建输入的哈希为键缓存构建好的二进制
+//
+// 键只由编译输入计算得出：主模块中参与构建的源文件(按目标平台和构建标签筛选)、
+// go.mod/go.sum、不含 -X 变量的构建参数、环境变量和Go版本。依赖模块由go.sum锁定，无需读取其源码。
+//
+// 版本号、提交SHA等通过 -X 注入的变量记录在缓存条目中，与本次构建相同时直接恢复二进制，
+// 不同时使用新的变量重新链接，编译结果由Go的构建缓存复用。构建时间不参与比较，
+// 直接恢复的二进制保留首次构建时注入的构建时间。
+type buildCache struct {
+	dir string
+}
+
+// newBuildCache 创建构建缓存，dir 为空时使用用户缓存目录
+func newBuildCache(dir string) (*buildCache, error) {
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("获取缓存目录失败: %w", err)
+		}
+		dir = filepath.Join(base, "parkercli", "build")
+	}
+	return &buildCache{dir: dir}, nil
+}
+
+// Key 计算构建的缓存键
+//
+// args 为 go build 的参数(不含输出路径)，env 为构建使用的环境变量。
+func (c *buildCache) Key(ctx context.Context, opts BuildOptions, args, env []string) (string, error) {
+	h := sha256.New()
+
+	goVersion, err := commandOutput(ctx, "go", "env", "GOVERSION")
+	if err != nil {
+		return "", fmt.Errorf("获取Go版本失败: %w", err)
+	}
+	fmt.Fprintf(h, "go %s\n", goVersion)
+	fmt.Fprintf(h, "args %s\n", strings.Join(args, "\x00"))
+	fmt.Fprintf(h, "target %s/%s\n", opts.GoOS, opts.GoArch)
+
+	// 只记录影响编译结果的环境变量，最后出现的值生效
+	values := make(map[string]string)
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok && (containsKey(cacheEnvKeys, k) || k == "GOOS" || k == "GOARCH") {
+			values[k] = v
+		}
+	}
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(h, "env %s=%s\n", k, values[k])
+	}
+
+	files, err := c.sourceFiles(ctx, opts, env)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if err := hashFile(h, file); err != nil {
+			return "", fmt.Errorf("读取源文件 %s 失败: %w", file, err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sourceFiles 返回主模块中参与构建的文件以及go.mod、go.sum，按路径排序
+func (c *buildCache) sourceFiles(ctx context.Context, opts BuildOptions, env []string) ([]string, error) {
+	args := []string{"list", "-deps", "-f", sourceListTemplate}
+	if len(opts.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(opts.Tags, ","))
+	}
+	if opts.MainFile != "" {
+		args = append(args, opts.MainFile)
+	}
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("列出源文件失败: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var files []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		parts := strings.Split(strings.TrimSpace(scanner.Text()), "|")
+		if len(parts) < 2 {
+			continue
+		}
+		for _, name := range parts[1:] {
+			files = append(files, filepath.Join(parts[0], name))
+		}
+	}
+
+	if gomod, err := commandOutput(ctx, "go", "env", "GOMOD"); err == nil && gomod != "" && gomod != os.DevNull {
+		files = append(files, gomod)
+		if gosum := filepath.Join(filepath.Dir(gomod), "go.sum"); utils.FileExists(gosum) {
+			files = append(files, gosum)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// path 返回缓存键对应的文件路径
+func (c *buildCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// cacheEntry 缓存条目的元数据，与二进制一同保存
+type cacheEntry struct {
+	BuildTime string            `json:"build_time"` // 二进制中注入的构建时间
+	Vars      map[string]string `json:"vars"`       // 二进制中通过 -X 注入的变量，不含构建时间
+}
+
+// Lookup 读取缓存条目的元数据，二进制或元数据不存在时返回false
+func (c *buildCache) Lookup(key string) (cacheEntry, bool) {
+	var entry cacheEntry
+	src := c.path(key)
+	if !utils.FileExists(src) {
+		return entry, false
+	}
+	data, err := os.ReadFile(src + ".json")
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logger.Warn("解析缓存元数据失败: %v", err)
+		return entry, false
+	}
+	return entry, true
+}
+
+// Restore 将缓存的二进制复制到输出路径
+func (c *buildCache) Restore(key, dst string) error {
+	if err := copyFile(c.path(key), dst); err != nil {
+		return fmt.Errorf("从缓存恢复失败: %w", err)
+	}
+	return nil
+}
+
+// Store 将构建好的二进制及其元数据写入缓存
+func (c *buildCache) Store(key, src string, entry cacheEntry) error {
+	dst := c.path(key)
+	if err := utils.EnsureDir(filepath.Dir(dst)); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("写入缓存失败: %w", err)
+	}
+	defer in.Close()
+	if err := writeFileAtomic(dst, in, 0755); err != nil {
+		return fmt.Errorf("写入缓存失败: %w", err)
+	}
+
+	// 元数据最后写入，二进制写入失败时不会留下指向旧二进制的元数据
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("写入缓存元数据失败: %w", err)
+	}
+	if err := writeFileAtomic(dst+".json", bytes.NewReader(data), 0644); err != nil {
+		return fmt.Errorf("写入缓存元数据失败: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名，并行构建时不会读到写了一半的文件
+func writeFileAtomic(dst string, r io.Reader, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// copyFile 复制可执行文件
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// hashFile 将文件路径和内容写入哈希
+func hashFile(h io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(h, "file %s\n", path)
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// containsKey 判断列表中是否包含指定值
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys 返回排序后的map键
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,321 @@
+package builder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parker/ParkerCli/internal/config"
+	"github.com/parker/ParkerCli/internal/utils"
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// OCI镜像标签，见 https://github.com/opencontainers/image-spec/blob/main/annotations.md
+const (
+	LabelCreated  = "org.opencontainers.image.created"
+	LabelRevision = "org.opencontainers.image.revision"
+	LabelVersion  = "org.opencontainers.image.version"
+	LabelTitle    = "org.opencontainers.image.title"
+)
+
+// BuildDocker 构建Docker镜像
+//
+// 指定了目标平台、推送或缓存导出时使用 docker buildx build，否则使用 docker build。
+// 多个目标平台的镜像无法加载到本地，必须同时推送到镜像仓库。
+func (b *StandardBuilder) BuildDocker(ctx context.Context, opts BuildOptions) (*BuildResult, error) {
+	startTime := time.Now()
+	result := &BuildResult{
+		BuildTime: startTime,
+	}
+
+	// 检查docker命令是否可用
+	if err := exec.Command("docker", "--version").Run(); err != nil {
+		return result, fmt.Errorf("docker命令不可用，请确保已安装Docker: %w", err)
+	}
+	if useBuildx(opts) {
+		if err := exec.Command("docker", "buildx", "version").Run(); err != nil {
+			return result, fmt.Errorf("docker buildx不可用，请安装buildx插件: %w", err)
+		}
+	}
+
+	// 设置默认Dockerfile路径
+	if opts.Dockerfile == "" {
+		opts.Dockerfile = "Dockerfile"
+	}
+
+	// 确保Dockerfile存在
+	if !utils.FileExists(opts.Dockerfile) {
+		return result, fmt.Errorf("Dockerfile不存在: %s", opts.Dockerfile)
+	}
+
+	// 获取镜像名称
+	imageName := opts.DockerImage
+	if imageName == "" {
+		// 使用配置的namespace和应用名称
+		cfg := config.GetAll()
+		namespace := cfg.Docker.Namespace
+		if namespace == "" {
+			namespace = "myapp"
+		}
+		imageName = fmt.Sprintf("%s/%s", namespace, opts.Name)
+	}
+
+	// 添加标签
+	tags := opts.DockerTags
+	if len(tags) == 0 {
+		if opts.Version != "" {
+			tags = append(tags, opts.Version)
+		}
+		tags = append(tags, "latest")
+	}
+
+	// 推送前登录镜像仓库
+	if opts.Push && opts.RegistryUser != "" {
+		if err := dockerLogin(ctx, opts.Registry, opts.RegistryUser, opts.RegistryPassword); err != nil {
+			return result, err
+		}
+	}
+
+	// 通过buildx的元数据文件获取推送后的镜像摘要
+	var metadataFile string
+	if useBuildx(opts) {
+		f, err := os.CreateTemp("", "parkercli-buildx-*.json")
+		if err != nil {
+			return result, fmt.Errorf("创建临时文件失败: %w", err)
+		}
+		f.Close()
+		metadataFile = f.Name()
+		defer os.Remove(metadataFile)
+	}
+
+	// 构建Docker命令
+	info := opts.BuildInfo
+	if info == nil {
+		collected := CollectBuildInfo(ctx)
+		info = &collected
+	}
+	args, err := dockerBuildArgs(opts, imageName, tags, imageLabels(opts, *info), metadataFile)
+	if err != nil {
+		return result, err
+	}
+
+	// 创建Docker构建命令
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// 执行构建
+	logger.Info("开始构建Docker镜像: %s", imageName)
+	logger.Debug("构建命令: docker %s", strings.Join(args, " "))
+
+	if err := cmd.Run(); err != nil {
+		result.Success = false
+		result.ErrorMessage = err.Error()
+		return result, fmt.Errorf("Docker构建失败: %w", err)
+	}
+
+	// 多平台镜像只存在于镜像仓库中，使用推送后的摘要作为镜像ID
+	if len(opts.Platforms) <= 1 {
+		inspectLocalImage(result, fmt.Sprintf("%s:%s", imageName, tags[0]))
+	}
+	if result.ImageID == "" && metadataFile != "" {
+		result.ImageID = buildxDigest(metadataFile)
+	}
+
+	// 计算构建时间
+	duration := time.Since(startTime)
+	result.Duration = duration.Seconds()
+	result.Success = true
+	result.OutputPath = imageName
+
+	logger.Info("Docker构建成功: %s (%s) (大小: %s, 耗时: %.2f秒)",
+		imageName, strings.Join(tags, ", "), utils.BytesToHumanReadable(result.ImageSize), result.Duration)
+	if opts.Push {
+		logger.Info("镜像已推送: %s", imageName)
+	}
+
+	return result, nil
+}
+
+// useBuildx 判断是否需要使用 buildx 构建
+func useBuildx(opts BuildOptions) bool {
+	return len(opts.Platforms) > 0 || opts.Push || len(opts.CacheTo) > 0 || opts.DockerBuilder != ""
+}
+
+// dockerBuildArgs 生成镜像构建命令的参数
+func dockerBuildArgs(opts BuildOptions, imageName string, tags []string, labels map[string]string, metadataFile string) ([]string, error) {
+	buildx := useBuildx(opts)
+	if len(opts.Platforms) > 1 && !opts.Push {
+		return nil, fmt.Errorf("多平台镜像无法加载到本地，请同时指定推送(--push)")
+	}
+	if len(opts.CacheTo) > 0 && !buildx {
+		return nil, fmt.Errorf("导出构建缓存需要使用buildx")
+	}
+
+	var args []string
+	if buildx {
+		args = append(args, "buildx", "build")
+		if opts.DockerBuilder != "" {
+			args = append(args, "--builder", opts.DockerBuilder)
+		}
+		if len(opts.Platforms) > 0 {
+			args = append(args, "--platform", strings.Join(opts.Platforms, ","))
+		}
+	} else {
+		args = append(args, "build")
+	}
+
+	// 添加标签
+	for _, tag := range tags {
+		args = append(args, "-t", fmt.Sprintf("%s:%s", imageName, tag))
+	}
+
+	// 添加构建参数，未指定VERSION时传入版本号
+	buildArgs := make(map[string]string, len(opts.BuildArgs)+1)
+	if opts.Version != "" {
+		buildArgs["VERSION"] = opts.Version
+	}
+	for k, v := range opts.BuildArgs {
+		buildArgs[k] = v
+	}
+	for _, k := range sortedKeys(buildArgs) {
+		args = append(args, "--build-arg", k+"="+buildArgs[k])
+	}
+
+	if opts.Target != "" {
+		args = append(args, "--target", opts.Target)
+	}
+
+	for _, k := range sortedKeys(labels) {
+		args = append(args, "--label", k+"="+labels[k])
+	}
+	// 推送时同时写入OCI注解，多平台镜像写入索引和各平台的清单
+	if buildx && opts.Push {
+		level := "manifest"
+		if len(opts.Platforms) > 1 {
+			level = "index,manifest"
+		}
+		for _, k := range sortedKeys(labels) {
+			if strings.HasPrefix(k, "org.opencontainers.image.") {
+				args = append(args, "--annotation", level+":"+k+"="+labels[k])
+			}
+		}
+	}
+
+	for _, from := range opts.CacheFrom {
+		args = append(args, "--cache-from", from)
+	}
+	for _, to := range opts.CacheTo {
+		args = append(args, "--cache-to", to)
+	}
+
+	// 是否启用缓存
+	if opts.CleanBuild {
+		args = append(args, "--no-cache")
+	}
+
+	if buildx {
+		// 单平台镜像不推送时加载到本地，与 docker build 的行为一致
+		if opts.Push {
+			args = append(args, "--push")
+		} else {
+			args = append(args, "--load")
+		}
+		if metadataFile != "" {
+			args = append(args, "--metadata-file", metadataFile)
+		}
+	}
+
+	// 指定Dockerfile路径
+	args = append(args, "-f", opts.Dockerfile)
+
+	// 当前目录作为构建上下文
+	args = append(args, ".")
+
+	return args, nil
+}
+
+// imageLabels 生成镜像的OCI标签，用户指定的标签优先
+func imageLabels(opts BuildOptions, info BuildInfo) map[string]string {
+	labels := make(map[string]string)
+	if opts.Name != "" {
+		labels[LabelTitle] = opts.Name
+	}
+	if opts.Version != "" {
+		labels[LabelVersion] = opts.Version
+	}
+	if info.Commit != "" {
+		labels[LabelRevision] = info.Commit
+	}
+	if info.BuildTime != "" {
+		labels[LabelCreated] = info.BuildTime
+	}
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// dockerLogin 使用配置的账号登录镜像仓库，密码通过标准输入传递，避免出现在进程列表中
+func dockerLogin(ctx context.Context, registry, username, password string) error {
+	args := []string{"login", "--username", username, "--password-stdin"}
+	if registry != "" {
+		args = append(args, registry)
+	}
+
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdin = strings.NewReader(password)
+	logger.Info("登录镜像仓库: %s", registry)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("登录镜像仓库失败: %w\n%s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// inspectLocalImage 读取本地镜像的ID和大小
+func inspectLocalImage(result *BuildResult, image string) {
+	// 获取镜像ID
+	imageIDCmd := exec.Command("docker", "images", "-q", image)
+	imageIDBytes, err := imageIDCmd.Output()
+	if err != nil {
+		logger.Warn("获取镜像ID失败: %v", err)
+	} else {
+		result.ImageID = strings.TrimSpace(string(imageIDBytes))
+	}
+
+	// 获取镜像大小
+	if result.ImageID != "" {
+		inspectCmd := exec.Command("docker", "image", "inspect", "-f", "{{.Size}}", result.ImageID)
+		sizeBytes, err := inspectCmd.Output()
+		if err != nil {
+			logger.Warn("获取镜像大小失败: %v", err)
+		} else {
+			size, err := strconv.ParseInt(strings.TrimSpace(string(sizeBytes)), 10, 64)
+			if err == nil {
+				result.ImageSize = size
+			}
+		}
+	}
+}
+
+// buildxDigest 从buildx的元数据文件中读取镜像摘要
+func buildxDigest(metadataFile string) string {
+	data, err := os.ReadFile(metadataFile)
+	if err != nil {
+		logger.Warn("读取构建元数据失败: %v", err)
+		return ""
+	}
+	var metadata struct {
+		Digest string `json:"containerimage.digest"`
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		logger.Warn("解析构建元数据失败: %v", err)
+		return ""
+	}
+	return metadata.Digest
+}
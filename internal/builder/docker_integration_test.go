@@ -0,0 +1,113 @@
+//go:build integration
+
+package builder
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试使用buildx构建多平台镜像并推送到本地镜像仓库
+//
+// 需要本地安装Docker和buildx插件，通过 go test -tags integration 运行。
+func TestBuildDockerMultiPlatformPush(t *testing.T) {
+	if err := exec.Command("docker", "buildx", "version").Run(); err != nil {
+		t.Skipf("docker buildx 不可用: %v", err)
+	}
+	ctx := context.Background()
+
+	// 启动本地镜像仓库，端口由Docker随机分配
+	out, err := exec.Command("docker", "run", "-d", "--rm", "-p", "127.0.0.1::5000", "registry:2").Output()
+	if err != nil {
+		t.Fatalf("启动镜像仓库失败: %v", err)
+	}
+	container := strings.TrimSpace(string(out))
+	t.Cleanup(func() { exec.Command("docker", "rm", "-f", container).Run() })
+
+	out, err = exec.Command("docker", "port", container, "5000/tcp").Output()
+	if err != nil {
+		t.Fatalf("获取镜像仓库端口失败: %v", err)
+	}
+	_, port, _ := strings.Cut(strings.Fields(string(out))[0], ":")
+	registry := "localhost:" + port
+
+	// docker-container 驱动的构建器使用主机网络才能访问本地镜像仓库
+	builderName := "parkercli-test-" + container[:12]
+	if out, err := exec.Command("docker", "buildx", "create", "--name", builderName,
+		"--driver", "docker-container", "--driver-opt", "network=host").CombinedOutput(); err != nil {
+		t.Fatalf("创建buildx构建器失败: %v\n%s", err, out)
+	}
+	t.Cleanup(func() { exec.Command("docker", "buildx", "rm", builderName).Run() })
+
+	// 构建上下文不使用RUN指令，无需模拟其他架构
+	dir := t.TempDir()
+	dockerfile := `FROM scratch AS base
+ARG VERSION
+COPY version.txt /version.txt
+
+FROM base AS release
+LABEL stage=release
+`
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "version.txt"), []byte("1.2.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	image := registry + "/parkercli/app"
+	opts := BuildOptions{
+		Type:          TypeDocker,
+		Name:          "app",
+		Version:       "1.2.0",
+		Dockerfile:    "Dockerfile",
+		DockerImage:   image,
+		DockerTags:    []string{"1.2.0"},
+		Platforms:     []string{"linux/amd64", "linux/arm64"},
+		Push:          true,
+		Target:        "release",
+		BuildArgs:     map[string]string{"VERSION": "1.2.0"},
+		CacheTo:       []string{"type=inline"},
+		DockerBuilder: builderName,
+		BuildInfo:     &BuildInfo{Commit: "3f2c1a9b", BuildTime: "2024-01-02T15:04:05Z"},
+	}
+
+	result, err := NewStandardBuilder().BuildDocker(ctx, opts)
+	if err != nil {
+		t.Fatalf("构建镜像失败: %v", err)
+	}
+	if !result.Success || !strings.HasPrefix(result.ImageID, "sha256:") {
+		t.Errorf("构建结果不正确: %+v", result)
+	}
+
+	// 推送的镜像索引包含两个平台，并带有提交和版本注解
+	out, err = exec.Command("docker", "buildx", "imagetools", "inspect", "--raw", image+":1.2.0").Output()
+	if err != nil {
+		t.Fatalf("读取镜像索引失败: %v", err)
+	}
+	index := strings.Join(strings.Fields(string(out)), "")
+	for _, want := range []string{`"architecture":"amd64"`, `"architecture":"arm64"`, LabelRevision, "3f2c1a9b", LabelVersion} {
+		if !strings.Contains(index, want) {
+			t.Errorf("镜像索引缺少 %s:\n%s", want, index)
+		}
+	}
+
+	// 多平台镜像必须推送
+	opts.Push = false
+	if _, err := NewStandardBuilder().BuildDocker(ctx, opts); err == nil {
+		t.Error("多平台镜像不推送时应返回错误")
+	}
+}
@@ -0,0 +1,190 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LDFlags 链接参数，构建时转换为 go build 的 -ldflags
+type LDFlags struct {
+	Strip      bool              // 剔除符号表和调试信息(-s -w)
+	Vars       map[string]string // 通过 -X 设置的字符串变量，键为完整变量路径，如 main.Version
+	ExtLDFlags []string          // 传给外部链接器的参数(-extldflags)
+	Static     bool              // 静态链接，向外部链接器传递 -static
+	Extra      []string          // 其他链接参数，按原样传递
+}
+
+// ParseLDFlags 将 -ldflags 字符串解析为结构化的链接参数
+//
+// 同时包含 -s 和 -w 时视为剔除符号，-extldflags 中的 -static 视为静态链接，
+// 无法识别的参数保存在 Extra 中。
+func ParseLDFlags(s string) (LDFlags, error) {
+	var flags LDFlags
+	fields, err := splitQuoted(s)
+	if err != nil {
+		return flags, fmt.Errorf("解析ldflags失败: %w", err)
+	}
+
+	var strip, omitDWARF bool
+	for i := 0; i < len(fields); i++ {
+		name, value, hasValue := strings.Cut(fields[i], "=")
+		switch name {
+		case "-s":
+			strip = true
+		case "-w":
+			omitDWARF = true
+		case "-X", "-extldflags":
+			if !hasValue {
+				if i+1 >= len(fields) {
+					return flags, fmt.Errorf("解析ldflags失败: %s 缺少参数值", name)
+				}
+				i++
+				value = fields[i]
+			}
+			if name == "-X" {
+				if err := flags.setVar(value); err != nil {
+					return flags, err
+				}
+				continue
+			}
+			ext, err := splitQuoted(value)
+			if err != nil {
+				return flags, fmt.Errorf("解析extldflags失败: %w", err)
+			}
+			for _, arg := range ext {
+				if arg == "-static" {
+					flags.Static = true
+					continue
+				}
+				flags.ExtLDFlags = append(flags.ExtLDFlags, arg)
+			}
+		default:
+			flags.Extra = append(flags.Extra, fields[i])
+		}
+	}
+
+	// 只指定其中一个时按原样保留
+	flags.Strip = strip && omitDWARF
+	if strip != omitDWARF {
+		if strip {
+			flags.Extra = append(flags.Extra, "-s")
+		} else {
+			flags.Extra = append(flags.Extra, "-w")
+		}
+	}
+	return flags, nil
+}
+
+// setVar 解析 -X 的 path=value 参数
+func (f *LDFlags) setVar(s string) error {
+	path, value, ok := strings.Cut(s, "=")
+	if !ok || path == "" {
+		return fmt.Errorf("解析ldflags失败: -X 参数格式应为 path=value: %q", s)
+	}
+	if f.Vars == nil {
+		f.Vars = make(map[string]string)
+	}
+	f.Vars[path] = value
+	return nil
+}
+
+// Merge 返回合并了 other 的链接参数，other 中的 -X 变量覆盖同名变量
+func (f LDFlags) Merge(other LDFlags) LDFlags {
+	merged := LDFlags{
+		Strip:      f.Strip || other.Strip,
+		Static:     f.Static || other.Static,
+		ExtLDFlags: append(append([]string(nil), f.ExtLDFlags...), other.ExtLDFlags...),
+		Extra:      append(append([]string(nil), f.Extra...), other.Extra...),
+	}
+	if len(f.Vars)+len(other.Vars) > 0 {
+		merged.Vars = make(map[string]string, len(f.Vars)+len(other.Vars))
+		for k, v := range f.Vars {
+			merged.Vars[k] = v
+		}
+		for k, v := range other.Vars {
+			merged.Vars[k] = v
+		}
+	}
+	return merged
+}
+
+// String 返回传给 -ldflags 的参数字符串，-X 变量按路径排序，保证相同的参数生成相同的结果
+func (f LDFlags) String() string {
+	var args []string
+	if f.Strip {
+		args = append(args, "-s", "-w")
+	}
+	for _, path := range sortedKeys(f.Vars) {
+		args = append(args, "-X", quoteArg(path+"="+f.Vars[path]))
+	}
+
+	ext := f.ExtLDFlags
+	if f.Static && !containsKey(ext, "-static") {
+		ext = append(append([]string(nil), ext...), "-static")
+	}
+	if len(ext) > 0 {
+		quoted := make([]string, len(ext))
+		for i, arg := range ext {
+			quoted[i] = quoteArg(arg)
+		}
+		args = append(args, "-extldflags", quoteArg(strings.Join(quoted, " ")))
+	}
+
+	for _, arg := range f.Extra {
+		args = append(args, quoteArg(arg))
+	}
+	return strings.Join(args, " ")
+}
+
+// ParseTags 解析逗号或空格分隔的构建标签
+func ParseTags(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// quoteArg 为包含空白或以引号开头的参数加上引号
+//
+// go 命令按空白拆分 -ldflags，支持单引号和双引号但不支持转义，
+// 参数同时包含两种引号时去掉其中的单引号。
+func quoteArg(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n\r") && s[0] != '\'' && s[0] != '"' {
+		return s
+	}
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", "") + "'"
+}
+
+// splitQuoted 按空白拆分参数，与 go 命令拆分 -ldflags 的规则一致
+//
+// 以单引号或双引号开头的参数一直到对应的引号结束，参数中间的引号不作特殊处理。
+func splitQuoted(s string) ([]string, error) {
+	var fields []string
+	for {
+		s = strings.TrimLeft(s, " \t\n\r")
+		if s == "" {
+			break
+		}
+		if quote := s[0]; quote == '\'' || quote == '"' {
+			end := strings.IndexByte(s[1:], quote)
+			if end < 0 {
+				return nil, fmt.Errorf("引号未闭合: %s", s)
+			}
+			fields = append(fields, s[1:end+1])
+			s = s[end+2:]
+			continue
+		}
+		end := strings.IndexAny(s, " \t\n\r")
+		if end < 0 {
+			end = len(s)
+		}
+		fields = append(fields, s[:end])
+		s = s[end:]
+	}
+	return fields, nil
+}
@@ -0,0 +1,156 @@
+package builder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMainsDir 默认查找 main 包的目录
+const DefaultMainsDir = "cmd"
+
+// mainListTemplate 列出 main 包，每行格式为 包名|目录
+const mainListTemplate = `{{.Name}}|{{.Dir}}`
+
+// MainPackage 项目中的一个 main 包
+type MainPackage struct {
+	Name string // 二进制名称，即包所在目录名
+	Path string // 相对当前目录的包路径，如 ./cmd/api
+}
+
+// MainFilter main 包筛选条件，支持 path.Match 通配符，按二进制名称匹配
+type MainFilter struct {
+	Include []string // 只构建匹配的 main 包，为空时构建全部
+	Exclude []string // 排除匹配的 main 包，优先于 Include
+}
+
+// DiscoverMains 查找 dir 目录(含子目录)下的所有 main 包，dir 为空时使用 cmd
+//
+// 通过 go list 查找，遵循Go工具链的规则(忽略 testdata、_ 和 . 开头的目录)。
+func DiscoverMains(ctx context.Context, dir string) ([]MainPackage, error) {
+	if dir == "" {
+		dir = DefaultMainsDir
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("目录不存在: %s", dir)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("获取当前目录失败: %w", err)
+	}
+
+	pattern := "./" + path.Join(filepath.ToSlash(filepath.Clean(dir)), "...")
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-f", mainListTemplate, pattern)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("查找main包失败: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var mains []MainPackage
+	seen := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		name, pkgDir, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "|")
+		if !ok || name != "main" {
+			continue
+		}
+
+		rel, err := filepath.Rel(cwd, pkgDir)
+		if err != nil {
+			return nil, fmt.Errorf("计算包路径失败: %w", err)
+		}
+		pkg := MainPackage{
+			Name: filepath.Base(pkgDir),
+			Path: "./" + filepath.ToSlash(rel),
+		}
+		// 以目录名作为二进制名称，同名会导致输出互相覆盖
+		if other, ok := seen[pkg.Name]; ok {
+			return nil, fmt.Errorf("main包 %s 与 %s 的二进制名称重复: %s", pkg.Path, other, pkg.Name)
+		}
+		seen[pkg.Name] = pkg.Path
+		mains = append(mains, pkg)
+	}
+
+	if len(mains) == 0 {
+		return nil, fmt.Errorf("%s 目录下未找到main包", dir)
+	}
+	return mains, nil
+}
+
+// Filter 返回符合筛选条件的 main 包
+func (f MainFilter) Filter(mains []MainPackage) ([]MainPackage, error) {
+	var matched []MainPackage
+	for _, pkg := range mains {
+		excluded, err := matchAny(f.Exclude, pkg.Name)
+		if err != nil {
+			return nil, err
+		}
+		if excluded {
+			continue
+		}
+		if len(f.Include) > 0 {
+			included, err := matchAny(f.Include, pkg.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !included {
+				continue
+			}
+		}
+		matched = append(matched, pkg)
+	}
+	return matched, nil
+}
+
+// matchAny 判断名称是否匹配任一模式
+func matchAny(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return false, fmt.Errorf("筛选模式不正确 %q: %w", pattern, err)
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MainBuilds 为每个 main 包生成一份构建选项，二进制以包所在目录名命名
+func MainBuilds(opts BuildOptions, mains []MainPackage) []BuildOptions {
+	builds := make([]BuildOptions, 0, len(mains))
+	for _, pkg := range mains {
+		build := opts
+		build.Name = pkg.Name
+		build.MainFile = pkg.Path
+		build.Env = append([]string(nil), opts.Env...)
+		builds = append(builds, build)
+	}
+	return builds
+}
+
+// BuildMains 查找 dir 目录下的所有 main 包并并行构建，返回的结果与筛选后的 main 包一一对应
+func (b *StandardBuilder) BuildMains(ctx context.Context, opts BuildOptions, dir string, filter MainFilter, parallel int) ([]*BuildResult, error) {
+	mains, err := DiscoverMains(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	mains, err = filter.Filter(mains)
+	if err != nil {
+		return nil, err
+	}
+	if len(mains) == 0 {
+		return nil, fmt.Errorf("没有符合筛选条件的main包")
+	}
+
+	return b.BuildBinaries(ctx, MainBuilds(opts, mains), parallel)
+}
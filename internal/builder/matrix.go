@@ -0,0 +1,140 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/parker/ParkerCli/internal/utils"
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// Platform 构建目标平台
+type Platform struct {
+	GoOS   string
+	GoArch string
+}
+
+// String 返回 os/arch 形式的平台名称
+func (p Platform) String() string {
+	return p.GoOS + "/" + p.GoArch
+}
+
+// BuildBinaries 使用有限数量的并发任务构建多个二进制
+//
+// 返回的结果与 builds 一一对应，某个目标失败不会中断其他目标的构建；
+// 全部构建结束后，只要有目标失败就返回错误。parallel 小于1时按1处理。
+func (b *StandardBuilder) BuildBinaries(ctx context.Context, builds []BuildOptions, parallel int) ([]*BuildResult, error) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	if parallel > len(builds) {
+		parallel = len(builds)
+	}
+
+	results := make([]*BuildResult, len(builds))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result, err := b.BuildBinary(ctx, builds[i])
+				if err != nil {
+					logger.Error("构建 %s (%s/%s) 失败: %v", builds[i].Name, builds[i].GoOS, builds[i].GoArch, err)
+					if result.ErrorMessage == "" {
+						result.ErrorMessage = err.Error()
+					}
+				}
+				results[i] = result
+			}
+		}()
+	}
+
+dispatch:
+	for i := range builds {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var failed []string
+	for i, result := range results {
+		// 取消后未开始的目标记为失败
+		if result == nil {
+			results[i] = &BuildResult{
+				Name:         builds[i].Name,
+				GoOS:         builds[i].GoOS,
+				GoArch:       builds[i].GoArch,
+				ErrorMessage: "构建已取消",
+			}
+			result = results[i]
+		}
+		if !result.Success {
+			failed = append(failed, resultTarget(result, builds[i]))
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, fmt.Errorf("%d 个目标构建失败: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return results, nil
+}
+
+// resultTarget 返回构建结果对应的目标描述
+func resultTarget(result *BuildResult, opts BuildOptions) string {
+	target := Platform{GoOS: result.GoOS, GoArch: result.GoArch}.String()
+	if opts.Name != "" {
+		target = fmt.Sprintf("%s (%s)", opts.Name, target)
+	}
+	return target
+}
+
+// FormatBuildMatrix 格式化多目标构建结果汇总
+func FormatBuildMatrix(results []*BuildResult) string {
+	var builder strings.Builder
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+
+	builder.WriteString(fmt.Sprintf("构建结果 (成功 %d/%d):\n\n", succeeded, len(results)))
+	builder.WriteString(fmt.Sprintf("%-16s | %-16s | %-6s | %-10s | %-8s | %s\n", "名称", "目标", "状态", "大小", "耗时", "输出"))
+	builder.WriteString(strings.Repeat("-", 110) + "\n")
+
+	for _, result := range results {
+		status := "成功"
+		if result.CacheHit {
+			status = "缓存"
+		} else if result.Relinked {
+			status = "重链接"
+		}
+		size := utils.BytesToHumanReadable(result.Size)
+		output := result.OutputPath
+		if result.ArchivePath != "" {
+			output = result.ArchivePath
+		}
+		if !result.Success {
+			status = "失败"
+			size = ""
+			// 只显示错误信息的第一行，完整信息已在构建日志中输出
+			output = strings.SplitN(result.ErrorMessage, "\n", 2)[0]
+		}
+
+		builder.WriteString(fmt.Sprintf("%-16s | %-16s | %-6s | %-10s | %-8s | %s\n",
+			result.Name, Platform{GoOS: result.GoOS, GoArch: result.GoArch}, status, size,
+			fmt.Sprintf("%.2f秒", result.Duration), output))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,122 @@
+package builder
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/parker/ParkerCli/internal/config"
+)
+
+// ParsePlatform 解析 os/arch 形式的目标平台
+func ParsePlatform(s string) (Platform, error) {
+	goos, goarch, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok || goos == "" || goarch == "" {
+		return Platform{}, fmt.Errorf("目标平台格式不正确: %q，应为 os/arch", s)
+	}
+	return Platform{GoOS: goos, GoArch: goarch}, nil
+}
+
+// Profile 返回配置中指定名称的构建配置
+func Profile(name string) (config.BuildProfile, error) {
+	profiles := config.GetAll().Build.Profiles
+	// 配置中的键不区分大小写
+	profile, ok := profiles[strings.ToLower(name)]
+	if !ok {
+		return config.BuildProfile{}, fmt.Errorf("未配置构建配置: %s", name)
+	}
+	return profile, nil
+}
+
+// ProfileNames 返回配置中所有构建配置的名称
+func ProfileNames() []string {
+	profiles := config.GetAll().Build.Profiles
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile 将构建配置应用到构建选项，返回配置中的目标平台列表
+func ApplyProfile(opts *BuildOptions, profile config.BuildProfile) ([]Platform, error) {
+	if profile.Main != "" {
+		opts.MainFile = profile.Main
+	}
+	if profile.Output != "" {
+		opts.Name = profile.Output
+	}
+	if profile.Tags != "" {
+		opts.Tags = ParseTags(profile.Tags)
+	}
+	if profile.LDFlags != "" {
+		ldflags, err := ParseLDFlags(profile.LDFlags)
+		if err != nil {
+			return nil, err
+		}
+		opts.LDFlags = opts.LDFlags.Merge(ldflags)
+	}
+	// 合并链接参数时 Strip 取或，需要单独处理配置中的显式设置
+	if profile.Strip != nil {
+		opts.LDFlags.Strip = *profile.Strip
+	}
+	if profile.GCFlags != "" {
+		opts.GCFlags = profile.GCFlags
+	}
+	if profile.BuildMode != "" {
+		opts.BuildMode = profile.BuildMode
+	}
+	opts.LDFlags.Static = opts.LDFlags.Static || profile.Static
+	opts.TrimPath = opts.TrimPath || profile.TrimPath
+	opts.Race = opts.Race || profile.Race
+	opts.Env = append(opts.Env, profile.Env...)
+	if profile.CGO != nil {
+		if *profile.CGO {
+			opts.Env = append(opts.Env, "CGO_ENABLED=1")
+		} else {
+			opts.Env = append(opts.Env, "CGO_ENABLED=0")
+		}
+	}
+
+	var platforms []Platform
+	for _, target := range profile.Targets {
+		platform, err := ParsePlatform(target)
+		if err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, platform)
+	}
+	return platforms, nil
+}
+
+// ExpandPlatforms 为每个目标平台生成一份构建选项
+//
+// 多个目标平台时，二进制输出到 OutputPath/{os}_{arch}/ 下，归档文件仍输出到 OutputPath。
+// 没有指定目标平台时返回原构建选项。
+func ExpandPlatforms(opts BuildOptions, platforms []Platform) []BuildOptions {
+	if len(platforms) == 0 {
+		return []BuildOptions{opts}
+	}
+	if len(platforms) == 1 {
+		opts.GoOS = platforms[0].GoOS
+		opts.GoArch = platforms[0].GoArch
+		return []BuildOptions{opts}
+	}
+
+	builds := make([]BuildOptions, 0, len(platforms))
+	for _, platform := range platforms {
+		build := opts
+		build.GoOS = platform.GoOS
+		build.GoArch = platform.GoArch
+		build.OutputPath = filepath.Join(opts.OutputPath, platform.GoOS+"_"+platform.GoArch)
+		if build.ArchiveDir == "" {
+			build.ArchiveDir = opts.OutputPath
+		}
+		// 每个目标使用独立的环境变量切片，避免共享底层数组
+		build.Env = append([]string(nil), opts.Env...)
+		builds = append(builds, build)
+	}
+	return builds
+}
@@ -0,0 +1,4 @@
+package main
+
+// Windows平台专用文件，用于测试构建缓存按目标平台筛选源文件
+const platform = "windows"
@@ -0,0 +1,13 @@
+package main
+
+import "fmt"
+
+// 以下变量构建时通过 -ldflags 注入
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+func main() {
+	fmt.Println(Version, Commit)
+}
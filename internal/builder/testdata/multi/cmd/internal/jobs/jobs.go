@@ -0,0 +1,4 @@
+package jobs
+
+// Name 任务名称
+const Name = "jobs"
@@ -12,16 +12,19 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	AppName     string                 `mapstructure:"app_name"`
-	Version     string                 `mapstructure:"version"`
-	Debug       bool                   `mapstructure:"debug"`
-	Environment string                 `mapstructure:"environment"`
-	Server      ServerConfig           `mapstructure:"server"`
-	Database    DatabaseConfig         `mapstructure:"database"`
-	Log         LogConfig              `mapstructure:"log"`
-	Docker      DockerConfig           `mapstructure:"docker"`
-	Paths       map[string]string      `mapstructure:"paths"`
-	Settings    map[string]interface{} `mapstructure:"settings"`
+	AppName     string                    `mapstructure:"app_name"`
+	Version     string                    `mapstructure:"version"`
+	Debug       bool                      `mapstructure:"debug"`
+	Environment string                    `mapstructure:"environment"`
+	Server      ServerConfig              `mapstructure:"server"`
+	Database    DatabaseConfig            `mapstructure:"database"`
+	Databases   map[string]DatabaseTarget `mapstructure:"databases"`
+	Log         LogConfig                 `mapstructure:"log"`
+	Docker      DockerConfig              `mapstructure:"docker"`
+	Migrate     MigrateConfig             `mapstructure:"migrate"`
+	Build       BuildConfig               `mapstructure:"build"`
+	Paths       map[string]string         `mapstructure:"paths"`
+	Settings    map[string]interface{}    `mapstructure:"settings"`
 }
 
 // ServerConfig 服务器配置
@@ -43,6 +46,14 @@ type DatabaseConfig struct {
 	SSLMode  string `mapstructure:"ssl_mode"`
 }
 
+// DatabaseTarget 命名数据库配置，用于一个项目使用多个数据库的场景
+type DatabaseTarget struct {
+	DatabaseConfig `mapstructure:",squash"`
+	Migrations     string `mapstructure:"migrations"` // 迁移目录，默认为 paths.migrations 下与名称同名的子目录
+	Seeds          string `mapstructure:"seeds"`      // 种子数据目录，默认为 paths.seeds 下与名称同名的子目录
+	Schema         string `mapstructure:"schema"`     // 结构快照文件，设置了 paths.schema 时默认为其所在目录下的 <名称>.sql
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level      string `mapstructure:"level"`
@@ -62,6 +73,36 @@ type DockerConfig struct {
 	Password  string `mapstructure:"password"`
 }
 
+// MigrateConfig 数据库迁移配置
+type MigrateConfig struct {
+	LockTimeout     int  `mapstructure:"lock_timeout"`       // 等待迁移锁的超时时间(秒)
+	AllowOutOfOrder bool `mapstructure:"allow_out_of_order"` // 是否允许应用早于最新已应用迁移的待执行迁移
+}
+
+// BuildConfig 构建配置
+type BuildConfig struct {
+	VersionPackage string                  `mapstructure:"version_package"` // 接收构建信息的包路径，如 github.com/org/app/internal/version
+	VersionVars    map[string]string       `mapstructure:"version_vars"`    // 单独指定某项构建信息的变量路径，如 commit: github.com/org/app/internal/version.Commit
+	Profiles       map[string]BuildProfile `mapstructure:"profiles"`        // 命名的构建配置，通过 build code --profile 使用
+}
+
+// BuildProfile 命名的构建配置
+type BuildProfile struct {
+	Main      string   `mapstructure:"main"`      // 主文件或主包路径
+	Output    string   `mapstructure:"output"`    // 输出文件名
+	Tags      string   `mapstructure:"tags"`      // 构建标签，逗号分隔
+	LDFlags   string   `mapstructure:"ldflags"`   // 额外的链接参数
+	GCFlags   string   `mapstructure:"gcflags"`   // 编译参数
+	BuildMode string   `mapstructure:"buildmode"` // 构建模式，如 pie
+	Strip     *bool    `mapstructure:"strip"`     // 是否剔除调试信息，不设置时沿用默认值
+	Static    bool     `mapstructure:"static"`    // 是否静态链接
+	TrimPath  bool     `mapstructure:"trimpath"`  // 是否移除二进制中的本地路径
+	Race      bool     `mapstructure:"race"`      // 是否启用竞态检测
+	Env       []string `mapstructure:"env"`       // 额外的环境变量，格式为 KEY=VALUE
+	CGO       *bool    `mapstructure:"cgo"`       // 是否启用cgo，不设置时使用Go的默认值
+	Targets   []string `mapstructure:"targets"`   // 目标平台列表，格式为 os/arch
+}
+
 // DefaultConfig 默认配置
 var DefaultConfig = Config{
 	AppName:     "myapp",
@@ -96,8 +137,17 @@ var DefaultConfig = Config{
 		Registry:  "docker.io",
 		Namespace: "myapp",
 	},
+	Migrate: MigrateConfig{
+		LockTimeout:     60,
+		AllowOutOfOrder: false,
+	},
+	Build: BuildConfig{
+		VersionPackage: "main",
+	},
 	Paths: map[string]string{
 		"migrations": "./migrations",
+		"schema":     "", // 迁移后写入结构快照的文件，为空时不写入
+		"seeds":      "./seeds",
 		"logs":       "./logs",
 		"data":       "./data",
 	},
@@ -191,6 +241,14 @@ func setDefaults() {
 
 	v.SetDefault("docker.registry", DefaultConfig.Docker.Registry)
 	v.SetDefault("docker.namespace", DefaultConfig.Docker.Namespace)
+	// 账号密码通常通过环境变量 PARKERCLI_DOCKER_USERNAME/PARKERCLI_DOCKER_PASSWORD 提供
+	v.SetDefault("docker.username", DefaultConfig.Docker.Username)
+	v.SetDefault("docker.password", DefaultConfig.Docker.Password)
+
+	v.SetDefault("migrate.lock_timeout", DefaultConfig.Migrate.LockTimeout)
+	v.SetDefault("migrate.allow_out_of_order", DefaultConfig.Migrate.AllowOutOfOrder)
+
+	v.SetDefault("build.version_package", DefaultConfig.Build.VersionPackage)
 
 	for key, value := range DefaultConfig.Paths {
 		v.SetDefault(fmt.Sprintf("paths.%s", key), value)
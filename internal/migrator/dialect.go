@@ -0,0 +1,188 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dialect 封装不同数据库之间的差异
+type dialect interface {
+	// DriverName 返回database/sql使用的驱动名称
+	DriverName() string
+	// DriverImport 返回驱动的Go包导入路径
+	DriverImport() string
+	// Placeholder 返回第n个(从1开始)参数占位符
+	Placeholder(n int) string
+	// TableExists 检查表是否存在
+	TableExists(ctx context.Context, db *sql.DB, table string) (bool, error)
+	// Tables 返回当前数据库(schema)中的所有表
+	Tables(ctx context.Context, db *sql.DB) ([]string, error)
+	// DropTables 删除指定的表，忽略表之间的外键约束
+	DropTables(ctx context.Context, db *sql.DB, tables []string) error
+	// DumpSchema 导出数据库结构的建表语句，exclude 中的表不导出
+	DumpSchema(ctx context.Context, db *sql.DB, dsn string, exclude []string) ([]string, error)
+	// Inspect 读取数据库结构模型，exclude 中的表不读取
+	Inspect(ctx context.Context, db *sql.DB, exclude []string) (*schemaModel, error)
+	// AlterColumn 返回将列定义从 from 修改为 to 的语句，不支持时返回nil
+	AlterColumn(table string, from, to columnDef) []string
+	// DropIndex 返回删除索引的语句
+	DropIndex(idx indexDef) string
+	// Lock 获取迁移锁，超时返回 ErrLockTimeout；返回的函数用于释放锁
+	Lock(ctx context.Context, db *sql.DB, name string, timeout time.Duration) (func() error, error)
+	// ForceUnlock 清除残留的迁移锁
+	ForceUnlock(ctx context.Context, db *sql.DB, name string) error
+}
+
+// newDialect 根据配置中的驱动名称创建方言
+func newDialect(driver string) (dialect, error) {
+	switch driver {
+	case "postgres", "postgresql":
+		return postgresDialect{}, nil
+	case "mysql":
+		return mysqlDialect{}, nil
+	case "sqlite", "sqlite3":
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+}
+
+// postgresDialect PostgreSQL方言
+type postgresDialect struct{}
+
+func (postgresDialect) DriverName() string { return "postgres" }
+
+func (postgresDialect) DriverImport() string { return "github.com/lib/pq" }
+
+func (postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (postgresDialect) TableExists(ctx context.Context, db *sql.DB, table string) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists)
+	return exists, err
+}
+
+func (postgresDialect) Tables(ctx context.Context, db *sql.DB) ([]string, error) {
+	return queryTables(ctx, db, "SELECT tablename FROM pg_tables WHERE schemaname = current_schema()")
+}
+
+func (postgresDialect) DropTables(ctx context.Context, db *sql.DB, tables []string) error {
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = `"` + strings.ReplaceAll(table, `"`, `""`) + `"`
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", strings.Join(quoted, ", ")))
+	return err
+}
+
+// mysqlDialect MySQL方言
+type mysqlDialect struct{}
+
+func (mysqlDialect) DriverName() string { return "mysql" }
+
+func (mysqlDialect) DriverImport() string { return "github.com/go-sql-driver/mysql" }
+
+func (mysqlDialect) Placeholder(int) string { return "?" }
+
+func (mysqlDialect) TableExists(ctx context.Context, db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?",
+		table).Scan(&count)
+	return count > 0, err
+}
+
+func (mysqlDialect) Tables(ctx context.Context, db *sql.DB) ([]string, error) {
+	return queryTables(ctx, db,
+		"SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'")
+}
+
+func (mysqlDialect) DropTables(ctx context.Context, db *sql.DB, tables []string) error {
+	// FOREIGN_KEY_CHECKS 是会话级变量，需要在同一连接上执行
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SET FOREIGN_KEY_CHECKS = 1")
+
+	for _, table := range tables {
+		query := fmt.Sprintf("DROP TABLE IF EXISTS `%s`", strings.ReplaceAll(table, "`", "``"))
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("删除表 %s 失败: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// sqliteDialect SQLite方言
+type sqliteDialect struct{}
+
+func (sqliteDialect) DriverName() string { return "sqlite" }
+
+func (sqliteDialect) DriverImport() string { return "modernc.org/sqlite" }
+
+func (sqliteDialect) Placeholder(int) string { return "?" }
+
+func (sqliteDialect) TableExists(ctx context.Context, db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+	return count > 0, err
+}
+
+func (sqliteDialect) Tables(ctx context.Context, db *sql.DB) ([]string, error) {
+	return queryTables(ctx, db, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+}
+
+func (sqliteDialect) DropTables(ctx context.Context, db *sql.DB, tables []string) error {
+	// foreign_keys 是连接级设置，需要在同一连接上执行
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	var foreignKeys int
+	if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), fmt.Sprintf("PRAGMA foreign_keys = %d", foreignKeys))
+
+	for _, table := range tables {
+		query := fmt.Sprintf(`DROP TABLE IF EXISTS "%s"`, strings.ReplaceAll(table, `"`, `""`))
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("删除表 %s 失败: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// queryTables 执行查询并返回第一列的表名
+func queryTables(ctx context.Context, db *sql.DB, query string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
@@ -0,0 +1,431 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// DiffOptions 生成结构差异迁移的选项
+type DiffOptions struct {
+	SchemaFile     string // 描述目标结构的SQL文件
+	Name           string // 新迁移的名称
+	FromMigrations bool   // 与按现有迁移重建的数据库比较，而不是当前数据库
+	// 用于执行目标结构的影子数据库(会被清空)，SQLite可省略，由调用方关闭
+	Shadow *StandardMigrator
+}
+
+// schemaChange 一项结构变化的正向和反向语句
+type schemaChange struct {
+	Up   []string
+	Down []string
+}
+
+// Diff 比较目标结构文件与数据库的差异，生成包含正向和反向语句的新迁移
+//
+// 目标结构文件会先在影子数据库中执行，再与当前数据库(或按现有迁移重建的影子
+// 数据库)的结构比较，两边的类型名称和默认值由数据库统一规范化。SQLite会自动
+// 使用临时数据库作为影子数据库，其他数据库需要通过 Shadow 指定一个可以清空
+// 的数据库。结构没有差异时不创建迁移，返回nil。
+func (m *StandardMigrator) Diff(opts DiffOptions) (*Migration, error) {
+	logger.Info("比较 %s 与数据库结构的差异", opts.SchemaFile)
+
+	content, err := os.ReadFile(opts.SchemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取结构文件失败: %w", err)
+	}
+
+	ctx := context.Background()
+	if err := m.openDB(ctx); err != nil {
+		return nil, err
+	}
+
+	shadow, cleanup, err := m.shadowMigrator(opts.Shadow)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	exclude := m.internalTables()
+
+	var current *schemaModel
+	if opts.FromMigrations {
+		logger.Info("在影子数据库中按现有迁移重建结构")
+		if err := shadow.resetShadow(ctx); err != nil {
+			return nil, err
+		}
+		if err := shadow.Up(0); err != nil {
+			return nil, fmt.Errorf("在影子数据库中应用迁移失败: %w", err)
+		}
+		current, err = shadow.dialect.Inspect(ctx, shadow.db, exclude)
+	} else {
+		current, err = m.dialect.Inspect(ctx, m.db, exclude)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取数据库结构失败: %w", err)
+	}
+
+	if err := shadow.resetShadow(ctx); err != nil {
+		return nil, err
+	}
+	if err := shadow.applySchema(ctx, string(content)); err != nil {
+		return nil, err
+	}
+	desired, err := shadow.dialect.Inspect(ctx, shadow.db, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("读取目标结构失败: %w", err)
+	}
+
+	changes, manual := diffSchema(m.dialect, current, desired)
+	if len(changes) == 0 && len(manual) == 0 {
+		logger.Info("数据库结构与 %s 一致，无需生成迁移", opts.SchemaFile)
+		return nil, nil
+	}
+	for _, note := range manual {
+		logger.Warn("需要手动处理: %s", note)
+	}
+
+	migrationContent := diffContent(opts, changes, manual)
+	if m.dryRun != nil {
+		_, err := fmt.Fprint(m.dryRun, migrationContent)
+		return nil, err
+	}
+
+	migration, err := m.Create(opts.Name, TypeSQL)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(migration.FilePath, []byte(migrationContent), 0644); err != nil {
+		return nil, fmt.Errorf("写入迁移内容失败: %w", err)
+	}
+
+	logger.Info("已根据结构差异生成 %d 项变更", len(changes))
+	return migration, nil
+}
+
+// shadowMigrator 返回操作影子数据库的迁移器，未指定时为SQLite创建临时数据库，
+// 返回的函数用于关闭临时数据库并清理临时文件
+func (m *StandardMigrator) shadowMigrator(shadow *StandardMigrator) (*StandardMigrator, func(), error) {
+	tmpDir := ""
+
+	if shadow != nil {
+		if shadow.dbDSN == m.dbDSN {
+			return nil, nil, fmt.Errorf("影子数据库 %s 不能与目标数据库相同", shadow.target)
+		}
+		d, err := newDialect(shadow.dbDriver)
+		if err != nil {
+			return nil, nil, err
+		}
+		if d.DriverName() != m.dialect.DriverName() {
+			return nil, nil, fmt.Errorf("影子数据库 %s 的驱动 %s 与目标数据库不一致", shadow.target, shadow.dbDriver)
+		}
+	} else {
+		if _, ok := m.dialect.(sqliteDialect); !ok {
+			return nil, nil, fmt.Errorf("%s 数据库需要通过 --shadow-db 指定影子数据库", m.dbDriver)
+		}
+		dir, err := os.MkdirTemp("", "parkercli-shadow-")
+		if err != nil {
+			return nil, nil, fmt.Errorf("创建影子数据库失败: %w", err)
+		}
+		tmpDir = dir
+		shadow = &StandardMigrator{
+			target:   "shadow",
+			dbDriver: m.dbDriver,
+			dbDSN:    filepath.Join(dir, "shadow.db") + "?_pragma=busy_timeout(5000)",
+		}
+	}
+
+	// 影子数据库使用与目标数据库相同的迁移文件，且不写入结构快照
+	shadow.migrationsDir = m.migrationsDir
+	shadow.source = m.source
+	shadow.schemaFile = ""
+	shadow.schemaTable = m.schemaTable
+	shadow.seedTable = m.seedTable
+	shadow.lockTimeout = m.lockTimeout
+	shadow.outOfOrder = true
+
+	cleanup := func() {
+		if tmpDir == "" {
+			return
+		}
+		if err := shadow.Close(); err != nil {
+			logger.Warn("关闭影子数据库失败: %v", err)
+		}
+		os.RemoveAll(tmpDir)
+	}
+	return shadow, cleanup, nil
+}
+
+// resetShadow 清空影子数据库
+func (m *StandardMigrator) resetShadow(ctx context.Context) error {
+	if err := m.openDB(ctx); err != nil {
+		return fmt.Errorf("连接影子数据库失败: %w", err)
+	}
+	return m.dropAllTables(ctx)
+}
+
+// applySchema 在影子数据库中执行目标结构文件
+func (m *StandardMigrator) applySchema(ctx context.Context, content string) error {
+	// pg_dump 导出的函数体内包含分号，PostgreSQL可以一次执行多条语句
+	if _, ok := m.dialect.(postgresDialect); ok {
+		return m.applyPostgresSchema(ctx, content)
+	}
+
+	// 结构文件可以直接使用迁移文件格式，否则整个文件视为向上部分
+	if !strings.Contains(content, directivePrefix+directiveUp) {
+		content = directivePrefix + directiveUp + "\n" + content
+	}
+	parsed, err := ParseMigration(strings.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("解析结构文件失败: %w", err)
+	}
+	if err := execStatements(ctx, m.db, parsed.UpStatements); err != nil {
+		return fmt.Errorf("执行结构文件失败: %w", err)
+	}
+	return nil
+}
+
+// applyPostgresSchema 在影子数据库的单独连接上执行 PostgreSQL 结构文件
+//
+// pg_dump 格式的结构快照会将 search_path 设为空，之后读取结构时 current_schema()
+// 为NULL，目标结构为空。执行前去掉这些会话设置，执行后再重置该连接的
+// search_path，避免结构文件中其他的 SET 语句影响连接池中后续的查询。
+func (m *StandardMigrator) applyPostgresSchema(ctx context.Context, content string) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("连接影子数据库失败: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, cleanPgDump(content)); err != nil {
+		return fmt.Errorf("执行结构文件失败: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "RESET search_path"); err != nil {
+		return fmt.Errorf("重置 search_path 失败: %w", err)
+	}
+	return nil
+}
+
+// diffSchema 计算从 from 变为 to 所需的结构变化
+//
+// 返回的变化按执行顺序排列：新建表、新增列、修改列、删除旧索引、创建新索引、
+// 删除列、删除表；反向迁移按相反顺序执行。无法自动生成语句的变化(如已有表的
+// 约束变化、SQLite修改列定义)以说明文字返回。
+func diffSchema(d dialect, from, to *schemaModel) ([]schemaChange, []string) {
+	var (
+		createTables, addColumns, alterColumns []schemaChange
+		dropIndexes, createIndexes             []schemaChange
+		dropColumns, dropTables                []schemaChange
+		manual                                 []string
+	)
+
+	for _, name := range sortedTables(to, from) {
+		table := to.Tables[name]
+		createTables = append(createTables, schemaChange{
+			Up:   []string{createTableSQL(table)},
+			Down: []string{"DROP TABLE " + name},
+		})
+	}
+
+	for _, name := range sortedTables(from, to) {
+		table := from.Tables[name]
+		dropTables = append(dropTables, schemaChange{
+			Up:   []string{"DROP TABLE " + name},
+			Down: []string{createTableSQL(table)},
+		})
+	}
+	// 删除表按依赖的相反顺序执行
+	for i, j := 0, len(dropTables)-1; i < j; i, j = i+1, j-1 {
+		dropTables[i], dropTables[j] = dropTables[j], dropTables[i]
+	}
+
+	for _, name := range sortedKeys(to.Tables) {
+		old, ok := from.Tables[name]
+		if !ok {
+			continue
+		}
+		table := to.Tables[name]
+
+		for _, col := range table.Columns {
+			oldCol, ok := old.column(col.Name)
+			if !ok {
+				addColumns = append(addColumns, schemaChange{
+					Up:   []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", name, col.definition())},
+					Down: []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", name, col.Name)},
+				})
+				continue
+			}
+			if oldCol.definition() == col.definition() {
+				continue
+			}
+			up := d.AlterColumn(name, oldCol, col)
+			down := d.AlterColumn(name, col, oldCol)
+			if up == nil || down == nil {
+				manual = append(manual, fmt.Sprintf("表 %s 的列 %s 定义由 %q 变为 %q", name, col.Name, oldCol.definition(), col.definition()))
+				continue
+			}
+			alterColumns = append(alterColumns, schemaChange{Up: up, Down: down})
+		}
+
+		for _, col := range old.Columns {
+			if _, ok := table.column(col.Name); !ok {
+				dropColumns = append(dropColumns, schemaChange{
+					Up:   []string{fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", name, col.Name)},
+					Down: []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", name, col.definition())},
+				})
+			}
+		}
+
+		if strings.Join(old.Constraints, "\n") != strings.Join(table.Constraints, "\n") {
+			manual = append(manual, fmt.Sprintf("表 %s 的约束由 [%s] 变为 [%s]", name,
+				strings.Join(old.Constraints, "; "), strings.Join(table.Constraints, "; ")))
+		}
+	}
+
+	for _, name := range sortedKeys(from.Indexes) {
+		idx := from.Indexes[name]
+		newIdx, ok := to.Indexes[name]
+		if ok && newIdx.Definition == idx.Definition {
+			continue
+		}
+		// 随表一起删除的索引无需单独删除
+		if _, ok := to.Tables[idx.Table]; !ok {
+			continue
+		}
+		dropIndexes = append(dropIndexes, schemaChange{
+			Up:   []string{d.DropIndex(idx)},
+			Down: []string{idx.Definition},
+		})
+	}
+
+	for _, name := range sortedKeys(to.Indexes) {
+		idx := to.Indexes[name]
+		if old, ok := from.Indexes[name]; ok && old.Definition == idx.Definition {
+			continue
+		}
+		createIndexes = append(createIndexes, schemaChange{
+			Up:   []string{idx.Definition},
+			Down: []string{d.DropIndex(idx)},
+		})
+	}
+
+	// 删除的表上的索引在反向迁移重建表后需要恢复
+	for _, name := range sortedKeys(from.Indexes) {
+		idx := from.Indexes[name]
+		if _, ok := to.Tables[idx.Table]; !ok {
+			dropTables = append([]schemaChange{{Down: []string{idx.Definition}}}, dropTables...)
+		}
+	}
+
+	var changes []schemaChange
+	for _, group := range [][]schemaChange{createTables, addColumns, alterColumns, dropIndexes, createIndexes, dropColumns, dropTables} {
+		changes = append(changes, group...)
+	}
+	return changes, manual
+}
+
+// referencesPattern 匹配外键约束引用的表
+var referencesPattern = regexp.MustCompile(`(?i)REFERENCES\s+([\w."]+)`)
+
+// sortedTables 返回 a 中存在而 b 中不存在的表，被外键引用的表排在前面
+func sortedTables(a, b *schemaModel) []string {
+	pending := make(map[string]bool)
+	for name := range a.Tables {
+		if _, ok := b.Tables[name]; !ok {
+			pending[name] = true
+		}
+	}
+
+	var ordered []string
+	for len(pending) > 0 {
+		var ready []string
+		for name := range pending {
+			if !dependsOnPending(a.Tables[name], pending) {
+				ready = append(ready, name)
+			}
+		}
+		// 存在循环引用时按名称顺序输出剩余的表
+		if len(ready) == 0 {
+			for name := range pending {
+				ready = append(ready, name)
+			}
+		}
+		sort.Strings(ready)
+		for _, name := range ready {
+			delete(pending, name)
+		}
+		ordered = append(ordered, ready...)
+	}
+	return ordered
+}
+
+// dependsOnPending 判断表是否引用了尚未创建的其他表
+func dependsOnPending(table *tableDef, pending map[string]bool) bool {
+	for _, constraint := range table.Constraints {
+		for _, match := range referencesPattern.FindAllStringSubmatch(constraint, -1) {
+			ref := strings.Trim(match[1], `"`)
+			if i := strings.LastIndex(ref, "."); i >= 0 {
+				ref = strings.Trim(ref[i+1:], `"`)
+			}
+			if ref != table.Name && pending[ref] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortedKeys 返回排序后的map键
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// createTableSQL 根据表结构生成建表语句
+func createTableSQL(table *tableDef) string {
+	lines := make([]string, 0, len(table.Columns)+len(table.Constraints))
+	for _, col := range table.Columns {
+		lines = append(lines, "    "+col.definition())
+	}
+	for _, constraint := range table.Constraints {
+		lines = append(lines, "    "+constraint)
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n)", table.Name, strings.Join(lines, ",\n"))
+}
+
+// diffContent 生成结构差异迁移的文件内容
+func diffContent(opts DiffOptions, changes []schemaChange, manual []string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("-- 迁移: %s\n", opts.Name))
+	b.WriteString(fmt.Sprintf("-- 说明: 由 migrate diff 根据 %s 生成，提交前请检查\n", filepath.Base(opts.SchemaFile)))
+	for _, note := range manual {
+		b.WriteString("-- 需要手动处理: " + note + "\n")
+	}
+	b.WriteString("\n")
+
+	var up, down []string
+	for _, change := range changes {
+		up = append(up, change.Up...)
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		down = append(down, changes[i].Down...)
+	}
+
+	b.WriteString("-- 向上迁移\n")
+	b.WriteString(directivePrefix + directiveUp + "\n")
+	writeStatements(&b, up)
+	b.WriteString("-- 向下迁移\n")
+	b.WriteString(directivePrefix + directiveDown + "\n")
+	writeStatements(&b, down)
+	return b.String()
+}
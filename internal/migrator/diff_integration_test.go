@@ -0,0 +1,126 @@
+//go:build integration
+
+package migrator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// 测试使用 pg_dump 格式的结构快照生成 PostgreSQL 迁移
+//
+// 需要两个空的 PostgreSQL 数据库，通过 go test -tags integration 运行，连接信息来自环境变量：
+// PARKERCLI_TEST_PG_HOST(必填)、PARKERCLI_TEST_PG_PORT、PARKERCLI_TEST_PG_USER、
+// PARKERCLI_TEST_PG_PASSWORD、PARKERCLI_TEST_PG_DB、PARKERCLI_TEST_PG_SHADOW_DB。
+func TestDiffPostgresDumpSchema(t *testing.T) {
+	host := os.Getenv("PARKERCLI_TEST_PG_HOST")
+	if host == "" {
+		t.Skip("未设置 PARKERCLI_TEST_PG_HOST")
+	}
+	dsn := func(name string) string {
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			host, envOr("PARKERCLI_TEST_PG_PORT", "5432"), envOr("PARKERCLI_TEST_PG_USER", "postgres"),
+			os.Getenv("PARKERCLI_TEST_PG_PASSWORD"), name)
+	}
+
+	m := newTestMigrator(t)
+	m.dbDriver = "postgres"
+	m.dbDSN = dsn(envOr("PARKERCLI_TEST_PG_DB", "parkercli_test"))
+	t.Cleanup(func() {
+		if err := m.Fresh(); err != nil {
+			t.Errorf("清空测试数据库失败: %v", err)
+		}
+	})
+
+	shadow := NewStandardMigrator(Options{
+		Target: "shadow",
+		Driver: "postgres",
+		DSN:    dsn(envOr("PARKERCLI_TEST_PG_SHADOW_DB", "parkercli_shadow")),
+	})
+	defer shadow.Close()
+
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id SERIAL PRIMARY KEY, name TEXT NOT NULL);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+	if err := m.Up(0); err != nil {
+		t.Fatalf("应用迁移失败: %v", err)
+	}
+
+	// pg_dump 格式的目标结构，包含清空 search_path 的会话设置和 psql 元命令
+	schemaFile := filepath.Join(t.TempDir(), "schema.sql")
+	schema := `--
+-- PostgreSQL database dump
+--
+
+\restrict 3qkQ0fHc9aXbE2yTcL1pWcXs
+
+SET statement_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET check_function_bodies = false;
+SET client_min_messages = warning;
+
+SET default_tablespace = '';
+
+SET default_table_access_method = heap;
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    name text NOT NULL,
+    email text DEFAULT ''::text NOT NULL
+);
+
+CREATE SEQUENCE public.users_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+
+ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+\unrestrict 3qkQ0fHc9aXbE2yTcL1pWcXs
+`
+	if err := os.WriteFile(schemaFile, []byte(schema), 0644); err != nil {
+		t.Fatalf("写入结构文件失败: %v", err)
+	}
+
+	var buf bytes.Buffer
+	m.SetDryRun(&buf)
+	defer m.SetDryRun(nil)
+
+	if _, err := m.Diff(DiffOptions{SchemaFile: schemaFile, Name: "add email", Shadow: shadow}); err != nil {
+		t.Fatalf("生成差异失败: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "ADD COLUMN email") {
+		t.Errorf("输出缺少新增列语句:\n%s", out)
+	}
+	if strings.Contains(out, "DROP TABLE") {
+		t.Errorf("目标结构中存在的表不应被删除:\n%s", out)
+	}
+}
+
+// envOr 返回环境变量的值，未设置时返回默认值
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
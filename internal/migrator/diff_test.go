@@ -0,0 +1,136 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试根据目标结构文件生成迁移
+func TestDiff(t *testing.T) {
+	m := newTestMigrator(t)
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+	writeMigration(t, m, "20240102000000_create_posts.sql", `-- +migrate Up
+CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT);
+CREATE INDEX idx_posts_title ON posts (title);
+
+-- +migrate Down
+DROP TABLE posts;
+`)
+
+	schemaFile := filepath.Join(t.TempDir(), "schema.sql")
+	schema := `-- 目标结构
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT ''
+);
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+
+CREATE TABLE comments (
+    id INTEGER PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    body TEXT,
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
+`
+	if err := os.WriteFile(schemaFile, []byte(schema), 0644); err != nil {
+		t.Fatalf("写入结构文件失败: %v", err)
+	}
+
+	t.Run("From Migrations", func(t *testing.T) {
+		var buf bytes.Buffer
+		m.SetDryRun(&buf)
+		defer func() {
+			m.SetDryRun(nil)
+			m.Close()
+		}()
+
+		migration, err := m.Diff(DiffOptions{SchemaFile: schemaFile, Name: "sync", FromMigrations: true})
+		if err != nil {
+			t.Fatalf("生成差异失败: %v", err)
+		}
+		if migration != nil {
+			t.Error("dry-run模式下不应创建迁移文件")
+		}
+		if !strings.Contains(buf.String(), "ALTER TABLE users ADD COLUMN email TEXT NOT NULL DEFAULT ''") {
+			t.Errorf("输出缺少新增列语句:\n%s", buf.String())
+		}
+	})
+
+	if err := m.Up(0); err != nil {
+		t.Fatalf("应用迁移失败: %v", err)
+	}
+
+	migration, err := m.Diff(DiffOptions{SchemaFile: schemaFile, Name: "sync schema"})
+	if err != nil {
+		t.Fatalf("生成差异失败: %v", err)
+	}
+	if migration == nil {
+		t.Fatal("结构存在差异时应生成迁移")
+	}
+
+	content, err := os.ReadFile(migration.FilePath)
+	if err != nil {
+		t.Fatalf("读取生成的迁移失败: %v", err)
+	}
+	parsed, err := ParseMigration(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("解析生成的迁移失败: %v\n%s", err, content)
+	}
+
+	up := strings.Join(parsed.UpStatements, "\n")
+	for _, want := range []string{
+		"CREATE TABLE comments",
+		"FOREIGN KEY (user_id) REFERENCES users (id)",
+		"ALTER TABLE users ADD COLUMN email TEXT NOT NULL DEFAULT ''",
+		"CREATE UNIQUE INDEX idx_users_email ON users (email)",
+		"DROP TABLE posts",
+	} {
+		if !strings.Contains(up, want) {
+			t.Errorf("向上部分缺少 %q:\n%s", want, up)
+		}
+	}
+
+	down := strings.Join(parsed.DownStatements, "\n")
+	for _, want := range []string{
+		"CREATE TABLE posts",
+		"CREATE INDEX idx_posts_title ON posts (title)",
+		"ALTER TABLE users DROP COLUMN email",
+		"DROP TABLE comments",
+	} {
+		if !strings.Contains(down, want) {
+			t.Errorf("向下部分缺少 %q:\n%s", want, down)
+		}
+	}
+
+	// 应用生成的迁移后结构应与目标一致
+	if err := m.Up(0); err != nil {
+		t.Fatalf("应用生成的迁移失败: %v", err)
+	}
+	again, err := m.Diff(DiffOptions{SchemaFile: schemaFile, Name: "noop"})
+	if err != nil {
+		t.Fatalf("再次比较失败: %v", err)
+	}
+	if again != nil {
+		content, _ := os.ReadFile(again.FilePath)
+		t.Fatalf("应用后不应再有差异:\n%s", content)
+	}
+
+	// 回滚生成的迁移后恢复原有结构
+	if err := m.Down(1); err != nil {
+		t.Fatalf("回滚生成的迁移失败: %v", err)
+	}
+	exists, err := m.dialect.TableExists(context.Background(), m.db, "posts")
+	if err != nil || !exists {
+		t.Errorf("回滚后应恢复 posts 表: %v", err)
+	}
+}
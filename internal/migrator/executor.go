@@ -0,0 +1,137 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// applyMigration 执行单个迁移的向上部分并写入迁移记录
+//
+// SQL迁移默认在独立事务中执行，迁移记录与迁移语句在同一事务中提交，
+// 失败时不会留下执行了一半的迁移。注意MySQL的DDL语句会隐式提交事务。
+// 声明了 -- +migrate NoTransaction 的迁移和Go函数迁移不使用事务。
+// 执行失败时在迁移记录表中记录 FAILED 状态。
+func (m *StandardMigrator) applyMigration(ctx context.Context, migration Migration, batch int) error {
+	statements, useTx, err := m.migrationStatements(migration, true)
+	if err != nil {
+		return err
+	}
+
+	if useTx {
+		err = m.inTransaction(ctx, func(exec execer) error {
+			if err := execStatements(ctx, exec, statements); err != nil {
+				return err
+			}
+			return m.saveRecord(ctx, exec, migration, batch, StatusApplied)
+		})
+	} else {
+		if migration.Type == TypeGoFn {
+			err = m.runGoMigration(ctx, migration, true)
+		} else {
+			err = execStatements(ctx, m.db, statements)
+		}
+		if err == nil {
+			err = m.saveRecord(ctx, m.db, migration, batch, StatusApplied)
+		}
+	}
+
+	if err != nil {
+		m.recordFailure(ctx, migration, batch)
+		return err
+	}
+	return nil
+}
+
+// rollbackMigration 执行单个迁移的向下部分并删除迁移记录
+//
+// 在事务中回滚失败时数据库保持原状，迁移仍为已应用状态；
+// 不使用事务的迁移回滚失败时状态无法确定，记录为 FAILED。
+func (m *StandardMigrator) rollbackMigration(ctx context.Context, migration Migration) error {
+	statements, useTx, err := m.migrationStatements(migration, false)
+	if err != nil {
+		return err
+	}
+
+	if useTx {
+		return m.inTransaction(ctx, func(exec execer) error {
+			if err := execStatements(ctx, exec, statements); err != nil {
+				return err
+			}
+			return m.deleteRecord(ctx, exec, migration.ID)
+		})
+	}
+
+	if migration.Type == TypeGoFn {
+		err = m.runGoMigration(ctx, migration, false)
+	} else {
+		err = execStatements(ctx, m.db, statements)
+	}
+	if err != nil {
+		m.recordFailure(ctx, migration, migration.BatchID)
+		return err
+	}
+
+	return m.deleteRecord(ctx, m.db, migration.ID)
+}
+
+// migrationStatements 返回迁移要执行的SQL语句以及是否使用事务
+func (m *StandardMigrator) migrationStatements(migration Migration, up bool) ([]string, bool, error) {
+	if migration.Type == TypeGoFn {
+		return nil, false, nil
+	}
+
+	parsed, err := m.parseMigration(migration)
+	if err != nil {
+		return nil, false, err
+	}
+
+	statements := parsed.UpStatements
+	if !up {
+		statements = parsed.DownStatements
+	}
+
+	if len(statements) == 0 {
+		logger.Warn("迁移 %s 没有可执行的SQL", migration.ID)
+	}
+
+	return statements, !parsed.NoTransaction, nil
+}
+
+// inTransaction 在事务中执行函数，函数返回错误时回滚
+func (m *StandardMigrator) inTransaction(ctx context.Context, fn func(exec execer) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logger.Warn("回滚事务失败: %v", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
+
+// recordFailure 在迁移记录表中记录迁移失败
+func (m *StandardMigrator) recordFailure(ctx context.Context, migration Migration, batch int) {
+	if err := m.saveRecord(ctx, m.db, migration, batch, StatusFailed); err != nil {
+		logger.Error("记录迁移 %s 失败状态时出错: %v", migration.ID, err)
+	}
+}
+
+// execStatements 依次执行SQL语句
+func execStatements(ctx context.Context, exec execer, statements []string) error {
+	for i, stmt := range statements {
+		if _, err := exec.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("执行第%d条语句失败: %w\n%s", i+1, err, stmt)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package migrator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/parker/ParkerCli/internal/utils"
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+const (
+	// runnerDriverEnv 运行器读取数据库驱动的环境变量
+	runnerDriverEnv = "PARKERCLI_MIGRATE_DRIVER"
+	// runnerDSNEnv 运行器读取数据库连接串的环境变量
+	runnerDSNEnv = "PARKERCLI_MIGRATE_DSN"
+)
+
+// runnerTemplate Go迁移运行器源码模板
+var runnerTemplate = template.Must(template.New("runner").Parse(`// Code generated by ParkerCli. DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	_ "{{.DriverImport}}"
+
+	migrations "{{.ImportPath}}"
+)
+
+var registry = map[string][2]func(context.Context, *sql.DB) error{
+{{- range .IDs}}
+	"{{.}}": {migrations.Up_{{.}}, migrations.Down_{{.}}},
+{{- end}}
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "用法: runner up|down <id>")
+		os.Exit(2)
+	}
+
+	fns, ok := registry[os.Args[2]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未找到Go迁移: %s\n", os.Args[2])
+		os.Exit(2)
+	}
+
+	fn := fns[0]
+	if os.Args[1] == "down" {
+		fn = fns[1]
+	}
+
+	db, err := sql.Open(os.Getenv("{{.DriverEnv}}"), os.Getenv("{{.DSNEnv}}"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "打开数据库连接失败: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := fn(context.Background(), db); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
+
+// goRunner 编译后的Go迁移运行器
+type goRunner struct {
+	binary string // 运行器可执行文件路径
+	tmpDir string // 运行器二进制所在临时目录
+}
+
+// runGoMigration 执行Go函数迁移
+func (m *StandardMigrator) runGoMigration(ctx context.Context, migration Migration, up bool) error {
+	// 优先使用进程内注册的迁移函数
+	if fns, ok := lookupGoMigration(migration.ID); ok {
+		fn := fns.Up
+		if !up {
+			fn = fns.Down
+		}
+		if fn == nil {
+			return fmt.Errorf("Go迁移 %s 未注册Down函数", migration.ID)
+		}
+		return fn(ctx, m.db)
+	}
+
+	// 嵌入的迁移文件无法编译运行器
+	if m.source != nil {
+		return fmt.Errorf("Go迁移 %s 未通过 Register 注册", migration.ID)
+	}
+
+	if m.runner == nil {
+		runner, err := m.buildGoRunner(ctx)
+		if err != nil {
+			return err
+		}
+		m.runner = runner
+	}
+
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+
+	cmd := exec.CommandContext(ctx, m.runner.binary, direction, migration.ID)
+	cmd.Env = append(os.Environ(),
+		runnerDriverEnv+"="+m.dialect.DriverName(),
+		runnerDSNEnv+"="+m.dbDSN)
+
+	var stderr bytes.Buffer
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("执行Go迁移失败: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// buildGoRunner 为迁移目录中的Go迁移生成并编译运行器
+func (m *StandardMigrator) buildGoRunner(ctx context.Context) (*goRunner, error) {
+	migrations, err := m.findMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	var firstFile string
+	for _, migration := range migrations {
+		if migration.Type == TypeGoFn {
+			ids = append(ids, migration.ID)
+			if firstFile == "" {
+				firstFile = migration.FilePath
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("迁移目录中没有Go迁移")
+	}
+
+	absDir, err := filepath.Abs(m.migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("获取迁移目录绝对路径失败: %w", err)
+	}
+
+	moduleRoot, modulePath, err := findGoModule(absDir)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(moduleRoot, absDir)
+	if err != nil {
+		return nil, fmt.Errorf("计算迁移包路径失败: %w", err)
+	}
+	importPath := modulePath
+	if rel != "." {
+		importPath += "/" + filepath.ToSlash(rel)
+	}
+
+	// 校验迁移文件的包声明，避免生成无法编译的运行器
+	file, err := parser.ParseFile(token.NewFileSet(), firstFile, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, fmt.Errorf("解析Go迁移文件失败: %w", err)
+	}
+	if file.Name.Name == "main" {
+		return nil, fmt.Errorf("Go迁移不能位于main包中: %s", firstFile)
+	}
+
+	// 运行器源码必须位于项目模块内才能导入迁移包，以下划线开头的目录不会被 ./... 匹配
+	srcDir, err := os.MkdirTemp(moduleRoot, "_parkercli_runner_")
+	if err != nil {
+		return nil, fmt.Errorf("创建运行器目录失败: %w", err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	var src bytes.Buffer
+	if err := runnerTemplate.Execute(&src, map[string]interface{}{
+		"DriverImport": m.dialect.DriverImport(),
+		"ImportPath":   importPath,
+		"IDs":          ids,
+		"DriverEnv":    runnerDriverEnv,
+		"DSNEnv":       runnerDSNEnv,
+	}); err != nil {
+		return nil, fmt.Errorf("生成运行器源码失败: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(srcDir, "main.go"), src.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("写入运行器源码失败: %w", err)
+	}
+
+	binDir, err := os.MkdirTemp("", "parkercli-migrate-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	binary := filepath.Join(binDir, utils.ExecutableName("runner"))
+
+	logger.Info("编译Go迁移运行器: %s", importPath)
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", binary, "./"+filepath.Base(srcDir))
+	cmd.Dir = moduleRoot
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		os.RemoveAll(binDir)
+		return nil, fmt.Errorf("编译Go迁移运行器失败 (请确认项目已依赖 %s): %w\n%s",
+			m.dialect.DriverImport(), err, strings.TrimSpace(string(output)))
+	}
+
+	return &goRunner{binary: binary, tmpDir: binDir}, nil
+}
+
+// findGoModule 从指定目录向上查找go.mod，返回模块根目录和模块路径
+func findGoModule(dir string) (string, string, error) {
+	for current := dir; ; current = filepath.Dir(current) {
+		modFile := filepath.Join(current, "go.mod")
+		if utils.FileExists(modFile) {
+			modulePath, err := readModulePath(modFile)
+			if err != nil {
+				return "", "", err
+			}
+			return current, modulePath, nil
+		}
+
+		if parent := filepath.Dir(current); parent == current {
+			break
+		}
+	}
+	return "", "", fmt.Errorf("未找到迁移目录所属的Go模块(go.mod): %s", dir)
+}
+
+// readModulePath 读取go.mod中的模块路径
+func readModulePath(modFile string) (string, error) {
+	f, err := os.Open(modFile)
+	if err != nil {
+		return "", fmt.Errorf("打开go.mod失败: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("读取go.mod失败: %w", err)
+	}
+	return "", fmt.Errorf("go.mod中未声明模块路径: %s", modFile)
+}
@@ -0,0 +1,531 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// schemaModel 数据库结构模型，用于比较两个数据库的结构差异
+type schemaModel struct {
+	Tables  map[string]*tableDef
+	Indexes map[string]indexDef
+}
+
+// tableDef 表结构
+type tableDef struct {
+	Name        string
+	Columns     []columnDef
+	Constraints []string // 主键、外键、唯一约束等表级约束
+}
+
+// columnDef 列定义
+type columnDef struct {
+	Name       string
+	Type       string
+	NotNull    bool
+	Default    string // 默认值表达式，为空表示没有默认值
+	Extra      string // MySQL的 auto_increment 等附加属性
+	PrimaryKey bool   // SQLite的 INTEGER PRIMARY KEY 需要写在列定义中
+}
+
+// definition 返回列在建表语句中的定义
+func (c columnDef) definition() string {
+	parts := []string{c.Name}
+	// SQLite允许省略列类型
+	if c.Type != "" {
+		parts = append(parts, c.Type)
+	}
+	if c.PrimaryKey {
+		parts = append(parts, "PRIMARY KEY")
+	}
+	if c.NotNull {
+		parts = append(parts, "NOT NULL")
+	}
+	if c.Default != "" {
+		parts = append(parts, "DEFAULT "+c.Default)
+	}
+	if c.Extra != "" {
+		parts = append(parts, c.Extra)
+	}
+	return strings.Join(parts, " ")
+}
+
+// indexDef 索引定义
+type indexDef struct {
+	Name       string
+	Table      string
+	Definition string // 完整的 CREATE INDEX 语句
+}
+
+// newSchemaModel 创建空的结构模型
+func newSchemaModel() *schemaModel {
+	return &schemaModel{
+		Tables:  make(map[string]*tableDef),
+		Indexes: make(map[string]indexDef),
+	}
+}
+
+// table 返回指定名称的表，不存在时创建
+func (s *schemaModel) table(name string) *tableDef {
+	t, ok := s.Tables[name]
+	if !ok {
+		t = &tableDef{Name: name}
+		s.Tables[name] = t
+	}
+	return t
+}
+
+// column 返回指定名称的列
+func (t *tableDef) column(name string) (columnDef, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return columnDef{}, false
+}
+
+// sortConstraints 对约束排序，使比较结果稳定
+func (s *schemaModel) sortConstraints() {
+	for _, t := range s.Tables {
+		sort.Strings(t.Constraints)
+	}
+}
+
+// Inspect 通过 information_schema 和系统表读取PostgreSQL数据库结构
+func (postgresDialect) Inspect(ctx context.Context, db *sql.DB, exclude []string) (*schemaModel, error) {
+	model := newSchemaModel()
+
+	rows, err := db.QueryContext(ctx, `SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+    COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), a.attidentity::text
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE n.nspname = current_schema() AND c.relkind = 'r' AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY c.relname, a.attnum`)
+	if err != nil {
+		return nil, fmt.Errorf("读取列信息失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, identity string
+		var col columnDef
+		if err := rows.Scan(&table, &col.Name, &col.Type, &col.NotNull, &col.Default, &identity); err != nil {
+			return nil, fmt.Errorf("读取列信息失败: %w", err)
+		}
+		if containsString(exclude, table) {
+			continue
+		}
+
+		// 自增列还原为 serial 类型，生成建表语句时无需单独创建序列
+		if serial, ok := serialTypes[col.Type]; ok && col.Default == fmt.Sprintf("nextval('%s_%s_seq'::regclass)", table, col.Name) {
+			col.Type = serial
+			col.Default = ""
+		}
+		switch identity {
+		case "a":
+			col.Extra = "GENERATED ALWAYS AS IDENTITY"
+		case "d":
+			col.Extra = "GENERATED BY DEFAULT AS IDENTITY"
+		}
+		t := model.table(table)
+		t.Columns = append(t.Columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取列信息失败: %w", err)
+	}
+
+	// 约束不比较名称，只比较定义
+	if err := scanPairs(ctx, db, `SELECT cl.relname, pg_get_constraintdef(con.oid)
+FROM pg_constraint con
+JOIN pg_class cl ON cl.oid = con.conrelid
+JOIN pg_namespace n ON n.oid = cl.relnamespace
+WHERE n.nspname = current_schema() AND con.contype IN ('p', 'f', 'u', 'c')`, func(table, def string) {
+		if t, ok := model.Tables[table]; ok {
+			t.Constraints = append(t.Constraints, def)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("读取约束信息失败: %w", err)
+	}
+
+	// 主键和唯一约束自带的索引已经包含在约束中
+	rows, err = db.QueryContext(ctx, `SELECT i.tablename, i.indexname, i.indexdef FROM pg_indexes i
+WHERE i.schemaname = current_schema()
+AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conname = i.indexname AND con.contype IN ('p', 'u'))`)
+	if err != nil {
+		return nil, fmt.Errorf("读取索引信息失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var idx indexDef
+		if err := rows.Scan(&idx.Table, &idx.Name, &idx.Definition); err != nil {
+			return nil, fmt.Errorf("读取索引信息失败: %w", err)
+		}
+		if _, ok := model.Tables[idx.Table]; ok {
+			model.Indexes[idx.Name] = idx
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取索引信息失败: %w", err)
+	}
+
+	model.sortConstraints()
+	return model, nil
+}
+
+// serialTypes 整数类型对应的PostgreSQL自增类型
+var serialTypes = map[string]string{
+	"smallint": "smallserial",
+	"integer":  "serial",
+	"bigint":   "bigserial",
+}
+
+// AlterColumn 分别修改列的类型、非空约束和默认值
+func (postgresDialect) AlterColumn(table string, from, to columnDef) []string {
+	// 自增属性的变化无法通过 ALTER COLUMN 直接完成
+	if from.Extra != to.Extra || serialChanged(from.Type, to.Type) {
+		return nil
+	}
+
+	var statements []string
+	prefix := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", table, to.Name)
+	if from.Type != to.Type {
+		statements = append(statements, fmt.Sprintf("%s TYPE %s", prefix, to.Type))
+	}
+	if from.NotNull != to.NotNull {
+		if to.NotNull {
+			statements = append(statements, prefix+" SET NOT NULL")
+		} else {
+			statements = append(statements, prefix+" DROP NOT NULL")
+		}
+	}
+	if from.Default != to.Default {
+		if to.Default == "" {
+			statements = append(statements, prefix+" DROP DEFAULT")
+		} else {
+			statements = append(statements, fmt.Sprintf("%s SET DEFAULT %s", prefix, to.Default))
+		}
+	}
+	return statements
+}
+
+// serialChanged 判断两个类型之间是否有一个是自增类型
+func serialChanged(from, to string) bool {
+	isSerial := func(t string) bool { return strings.HasSuffix(t, "serial") }
+	return isSerial(from) != isSerial(to) || (isSerial(from) && from != to)
+}
+
+func (postgresDialect) DropIndex(idx indexDef) string {
+	return "DROP INDEX " + idx.Name
+}
+
+// Inspect 通过 information_schema 读取MySQL数据库结构
+func (mysqlDialect) Inspect(ctx context.Context, db *sql.DB, exclude []string) (*schemaModel, error) {
+	model := newSchemaModel()
+
+	rows, err := db.QueryContext(ctx, `SELECT c.TABLE_NAME, c.COLUMN_NAME, c.COLUMN_TYPE, c.IS_NULLABLE, c.COLUMN_DEFAULT, c.EXTRA, c.DATA_TYPE
+FROM information_schema.columns c
+JOIN information_schema.tables t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+WHERE c.TABLE_SCHEMA = DATABASE() AND t.TABLE_TYPE = 'BASE TABLE'
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`)
+	if err != nil {
+		return nil, fmt.Errorf("读取列信息失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, nullable, extra, dataType string
+		var def sql.NullString
+		var col columnDef
+		if err := rows.Scan(&table, &col.Name, &col.Type, &nullable, &def, &extra, &dataType); err != nil {
+			return nil, fmt.Errorf("读取列信息失败: %w", err)
+		}
+		if containsString(exclude, table) {
+			continue
+		}
+
+		col.NotNull = nullable == "NO"
+		col.Default = mysqlDefault(def, dataType, extra)
+		col.Extra = strings.TrimSpace(strings.ReplaceAll(extra, "DEFAULT_GENERATED", ""))
+
+		t := model.table(table)
+		t.Columns = append(t.Columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取列信息失败: %w", err)
+	}
+
+	// 按索引汇总列，PRIMARY 作为主键约束
+	type mysqlIndex struct {
+		table   string
+		unique  bool
+		columns []string
+	}
+	indexes := make(map[string]*mysqlIndex)
+	var order []string
+	rows, err = db.QueryContext(ctx, `SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME FROM information_schema.statistics
+WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`)
+	if err != nil {
+		return nil, fmt.Errorf("读取索引信息失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, name, column string
+		var nonUnique int
+		if err := rows.Scan(&table, &name, &nonUnique, &column); err != nil {
+			return nil, fmt.Errorf("读取索引信息失败: %w", err)
+		}
+		key := table + "." + name
+		idx, ok := indexes[key]
+		if !ok {
+			idx = &mysqlIndex{table: table, unique: nonUnique == 0}
+			indexes[key] = idx
+			order = append(order, key)
+		}
+		idx.columns = append(idx.columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取索引信息失败: %w", err)
+	}
+
+	for _, key := range order {
+		idx := indexes[key]
+		t, ok := model.Tables[idx.table]
+		if !ok {
+			continue
+		}
+		name := strings.TrimPrefix(key, idx.table+".")
+		columns := strings.Join(idx.columns, ", ")
+		if name == "PRIMARY" {
+			t.Constraints = append(t.Constraints, fmt.Sprintf("PRIMARY KEY (%s)", columns))
+			continue
+		}
+		unique := ""
+		if idx.unique {
+			unique = "UNIQUE "
+		}
+		model.Indexes[name] = indexDef{
+			Name:       name,
+			Table:      idx.table,
+			Definition: fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)", unique, name, idx.table, columns),
+		}
+	}
+
+	if err := scanPairs(ctx, db, `SELECT TABLE_NAME, CONCAT('FOREIGN KEY (', COLUMN_NAME, ') REFERENCES ',
+    REFERENCED_TABLE_NAME, ' (', REFERENCED_COLUMN_NAME, ')')
+FROM information_schema.key_column_usage
+WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL`, func(table, def string) {
+		if t, ok := model.Tables[table]; ok {
+			t.Constraints = append(t.Constraints, def)
+		}
+	}); err != nil {
+		return nil, fmt.Errorf("读取外键信息失败: %w", err)
+	}
+
+	model.sortConstraints()
+	return model, nil
+}
+
+// mysqlDefault 将 information_schema 中的默认值转换为SQL表达式
+func mysqlDefault(def sql.NullString, dataType, extra string) string {
+	if !def.Valid {
+		return ""
+	}
+	// 表达式默认值(如 CURRENT_TIMESTAMP)不加引号
+	if strings.Contains(extra, "DEFAULT_GENERATED") || strings.HasPrefix(def.String, "(") {
+		return def.String
+	}
+	switch dataType {
+	case "tinyint", "smallint", "mediumint", "int", "bigint", "decimal", "float", "double", "bit":
+		if _, err := strconv.ParseFloat(def.String, 64); err == nil {
+			return def.String
+		}
+	}
+	return "'" + strings.ReplaceAll(def.String, "'", "''") + "'"
+}
+
+// AlterColumn 使用 MODIFY COLUMN 整体替换列定义
+func (mysqlDialect) AlterColumn(table string, from, to columnDef) []string {
+	return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", table, to.definition())}
+}
+
+func (mysqlDialect) DropIndex(idx indexDef) string {
+	return fmt.Sprintf("DROP INDEX %s ON %s", idx.Name, idx.Table)
+}
+
+// AlterColumn SQLite不支持修改列定义，需要重建表
+func (sqliteDialect) AlterColumn(table string, from, to columnDef) []string {
+	return nil
+}
+
+func (sqliteDialect) DropIndex(idx indexDef) string {
+	return "DROP INDEX " + idx.Name
+}
+
+// Inspect 通过 PRAGMA 读取SQLite数据库结构
+func (sqliteDialect) Inspect(ctx context.Context, db *sql.DB, exclude []string) (*schemaModel, error) {
+	model := newSchemaModel()
+
+	tables, err := queryTables(ctx, db, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("读取表信息失败: %w", err)
+	}
+
+	for _, table := range tables {
+		if containsString(exclude, table) {
+			continue
+		}
+		t := model.table(table)
+
+		rows, err := db.QueryContext(ctx, "SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid", table)
+		if err != nil {
+			return nil, fmt.Errorf("读取表 %s 的列信息失败: %w", table, err)
+		}
+		var primary []string
+		for rows.Next() {
+			var col columnDef
+			var def sql.NullString
+			var pk int
+			if err := rows.Scan(&col.Name, &col.Type, &col.NotNull, &def, &pk); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("读取表 %s 的列信息失败: %w", table, err)
+			}
+			col.Type = strings.ToUpper(col.Type)
+			col.Default = def.String
+			if pk > 0 {
+				primary = append(primary, col.Name)
+			}
+			t.Columns = append(t.Columns, col)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("读取表 %s 的列信息失败: %w", table, err)
+		}
+
+		// 单列 INTEGER 主键是rowid的别名，需要写在列定义中
+		if len(primary) == 1 {
+			for i := range t.Columns {
+				if t.Columns[i].Name == primary[0] && t.Columns[i].Type == "INTEGER" {
+					t.Columns[i].PrimaryKey = true
+					primary = nil
+					break
+				}
+			}
+		}
+		if len(primary) > 0 {
+			t.Constraints = append(t.Constraints, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primary, ", ")))
+		}
+
+		fks, err := db.QueryContext(ctx, `SELECT id, "from", "table", "to" FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table)
+		if err != nil {
+			return nil, fmt.Errorf("读取表 %s 的外键信息失败: %w", table, err)
+		}
+		type foreignKey struct{ from, table, to []string }
+		keys := make(map[int]*foreignKey)
+		var ids []int
+		for fks.Next() {
+			var id int
+			var from, refTable string
+			var to sql.NullString
+			if err := fks.Scan(&id, &from, &refTable, &to); err != nil {
+				fks.Close()
+				return nil, fmt.Errorf("读取表 %s 的外键信息失败: %w", table, err)
+			}
+			fk, ok := keys[id]
+			if !ok {
+				fk = &foreignKey{table: []string{refTable}}
+				keys[id] = fk
+				ids = append(ids, id)
+			}
+			fk.from = append(fk.from, from)
+			if to.Valid {
+				fk.to = append(fk.to, to.String)
+			}
+		}
+		fks.Close()
+		for _, id := range ids {
+			fk := keys[id]
+			def := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s", strings.Join(fk.from, ", "), fk.table[0])
+			if len(fk.to) > 0 {
+				def += fmt.Sprintf(" (%s)", strings.Join(fk.to, ", "))
+			}
+			t.Constraints = append(t.Constraints, def)
+		}
+
+		// 唯一约束对应自动创建的索引，普通索引读取原始建索引语句
+		idxRows, err := db.QueryContext(ctx, "SELECT name, \"unique\", origin FROM pragma_index_list(?)", table)
+		if err != nil {
+			return nil, fmt.Errorf("读取表 %s 的索引信息失败: %w", table, err)
+		}
+		type sqliteIndex struct {
+			name   string
+			origin string
+		}
+		var indexes []sqliteIndex
+		for idxRows.Next() {
+			var idx sqliteIndex
+			var unique int
+			if err := idxRows.Scan(&idx.name, &unique, &idx.origin); err != nil {
+				idxRows.Close()
+				return nil, fmt.Errorf("读取表 %s 的索引信息失败: %w", table, err)
+			}
+			indexes = append(indexes, idx)
+		}
+		idxRows.Close()
+
+		for _, idx := range indexes {
+			switch idx.origin {
+			case "u":
+				columns, err := queryTables(ctx, db, fmt.Sprintf("SELECT name FROM pragma_index_info('%s') ORDER BY seqno", idx.name))
+				if err != nil {
+					return nil, fmt.Errorf("读取索引 %s 失败: %w", idx.name, err)
+				}
+				t.Constraints = append(t.Constraints, fmt.Sprintf("UNIQUE (%s)", strings.Join(columns, ", ")))
+			case "c":
+				var def string
+				if err := db.QueryRowContext(ctx, "SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?", idx.name).Scan(&def); err != nil {
+					return nil, fmt.Errorf("读取索引 %s 失败: %w", idx.name, err)
+				}
+				model.Indexes[idx.name] = indexDef{Name: idx.name, Table: table, Definition: normalizeSQL(def)}
+			}
+		}
+	}
+
+	model.sortConstraints()
+	return model, nil
+}
+
+// whitespacePattern 匹配连续空白
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// normalizeSQL 合并语句中的连续空白，便于比较
+func normalizeSQL(stmt string) string {
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(stmt, " "))
+}
+
+// scanPairs 执行返回两列字符串的查询，逐行回调
+func scanPairs(ctx context.Context, db *sql.DB, query string, fn func(a, b string)) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a, b string
+		if err := rows.Scan(&a, &b); err != nil {
+			return err
+		}
+		fn(a, b)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,298 @@
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// LintSeverity 表示检查结果的严重程度
+type LintSeverity string
+
+const (
+	// SeverityError 表示可能造成数据丢失或长时间锁表的操作
+	SeverityError LintSeverity = "error"
+	// SeverityWarning 表示需要人工确认的操作
+	SeverityWarning LintSeverity = "warning"
+)
+
+// LintIssue 表示迁移文件中的一个风险项
+type LintIssue struct {
+	MigrationID string       `json:"migration_id"`
+	Name        string       `json:"name"`
+	FilePath    string       `json:"file_path"`
+	Rule        string       `json:"rule"`
+	Severity    LintSeverity `json:"severity"`
+	Message     string       `json:"message"`
+	Statement   string       `json:"statement,omitempty"`
+}
+
+// lintRule 针对单条SQL语句的检查规则
+type lintRule struct {
+	Name     string
+	Severity LintSeverity
+	Message  string
+	Postgres bool // 只对PostgreSQL生效
+	Match    func(stmt string) bool
+}
+
+var (
+	dropTablePattern     = regexp.MustCompile(`(?i)\bDROP\s+TABLE\b`)
+	alterTablePattern    = regexp.MustCompile(`(?i)^ALTER\s+TABLE\b`)
+	dropTargetPattern    = regexp.MustCompile(`(?i)\bDROP\s+(\w+)`)
+	renamePattern        = regexp.MustCompile(`(?i)^(ALTER\s+TABLE\b.*\bRENAME\b|RENAME\s+TABLE\b)|^ALTER\s+TABLE\b.*\bCHANGE\s+(COLUMN\s+)?\S+\s+\S+`)
+	addColumnPattern     = regexp.MustCompile(`(?i)\bADD\s+(COLUMN\s+)?`)
+	notNullPattern       = regexp.MustCompile(`(?i)\bNOT\s+NULL\b`)
+	defaultPattern       = regexp.MustCompile(`(?i)\bDEFAULT\b`)
+	addConstraintPattern = regexp.MustCompile(`(?i)^(CONSTRAINT|PRIMARY|FOREIGN|UNIQUE|CHECK|INDEX|KEY)\b`)
+	createIndexPattern   = regexp.MustCompile(`(?i)\bCREATE\s+(UNIQUE\s+)?INDEX\b`)
+	concurrentlyPattern  = regexp.MustCompile(`(?i)\bINDEX\s+CONCURRENTLY\b`)
+)
+
+// lintRules 内置的语句检查规则
+var lintRules = []lintRule{
+	{
+		Name:     "drop-table",
+		Severity: SeverityError,
+		Message:  "删除表会丢失全部数据",
+		Match:    dropTablePattern.MatchString,
+	},
+	{
+		Name:     "drop-column",
+		Severity: SeverityError,
+		Message:  "删除列会丢失数据，且仍在使用该列的旧版本应用会出错",
+		Match:    dropColumn,
+	},
+	{
+		Name:     "rename-column",
+		Severity: SeverityWarning,
+		Message:  "重命名列或表会导致仍在使用旧名称的应用出错，建议分多次发布完成",
+		Match:    renamePattern.MatchString,
+	},
+	{
+		Name:     "not-null-without-default",
+		Severity: SeverityError,
+		Message:  "为已有表添加 NOT NULL 列但没有默认值，表中已有数据时会失败",
+		Match:    addNotNullWithoutDefault,
+	},
+	{
+		Name:     "non-concurrent-index",
+		Severity: SeverityWarning,
+		Message:  "创建索引会在建索引期间阻塞写入，建议使用 CREATE INDEX CONCURRENTLY 并声明 -- +migrate NoTransaction",
+		Postgres: true,
+		Match: func(stmt string) bool {
+			return createIndexPattern.MatchString(stmt) && !concurrentlyPattern.MatchString(stmt)
+		},
+	},
+}
+
+// dropColumn 检查 ALTER TABLE 语句是否删除了列
+func dropColumn(stmt string) bool {
+	if !alterTablePattern.MatchString(stmt) {
+		return false
+	}
+	for _, match := range dropTargetPattern.FindAllStringSubmatch(stmt, -1) {
+		// DROP 之后不是约束、索引或默认值时，省略了 COLUMN 关键字的也视为删除列
+		switch strings.ToUpper(match[1]) {
+		case "CONSTRAINT", "INDEX", "KEY", "PRIMARY", "FOREIGN", "CHECK", "DEFAULT", "NOT":
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// addNotNullWithoutDefault 检查 ALTER TABLE ... ADD COLUMN 是否添加了没有默认值的 NOT NULL 列
+func addNotNullWithoutDefault(stmt string) bool {
+	if !alterTablePattern.MatchString(stmt) {
+		return false
+	}
+	for _, loc := range addColumnPattern.FindAllStringIndex(stmt, -1) {
+		definition := strings.TrimSpace(columnDefinition(stmt[loc[1]:]))
+		if addConstraintPattern.MatchString(definition) {
+			continue
+		}
+		if notNullPattern.MatchString(definition) && !defaultPattern.MatchString(definition) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnDefinition 返回ADD子句中的列定义
+//
+// 定义在括号外的第一个逗号或分号处结束，NUMERIC(10,2) 这类类型参数中的逗号不会截断定义。
+func columnDefinition(s string) string {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return s[:i]
+			}
+			depth--
+		case ',', ';':
+			if depth == 0 {
+				return s[:i]
+			}
+		}
+	}
+	return s
+}
+
+// Lint 检查迁移文件中的高风险操作
+//
+// all为false时只检查待执行的迁移，需要连接数据库；
+// all为true时检查所有迁移文件，不连接数据库。
+func (m *StandardMigrator) Lint(all bool) ([]LintIssue, error) {
+	logger.Info("检查迁移文件")
+
+	var migrations []Migration
+	var err error
+	if all {
+		migrations, err = m.findMigrations()
+	} else {
+		migrations, err = m.loadMigrations(context.Background())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []LintIssue
+	for _, migration := range migrations {
+		if !all && migration.Status != StatusPending && migration.Status != StatusFailed {
+			continue
+		}
+		found, err := m.lintMigration(migration)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+
+	return issues, nil
+}
+
+// lintMigration 检查单个迁移
+func (m *StandardMigrator) lintMigration(migration Migration) ([]LintIssue, error) {
+	// Go函数迁移无法静态分析
+	if migration.Type != TypeSQL {
+		return nil, nil
+	}
+
+	parsed, err := m.parseMigration(migration)
+	if err != nil {
+		return nil, err
+	}
+
+	newIssue := func(rule string, severity LintSeverity, message, stmt string) LintIssue {
+		return LintIssue{
+			MigrationID: migration.ID,
+			Name:        migration.Name,
+			FilePath:    migration.FilePath,
+			Rule:        rule,
+			Severity:    severity,
+			Message:     message,
+			Statement:   stmt,
+		}
+	}
+
+	var issues []LintIssue
+	if !parsed.HasDown && !parsed.Squashed {
+		issues = append(issues, newIssue("missing-down", SeverityWarning, "迁移没有 Down 部分，无法回滚", ""))
+	}
+
+	postgres := m.dbDriver == "postgres" || m.dbDriver == "postgresql"
+	for _, stmt := range parsed.UpStatements {
+		code := stripSQLComments(stmt)
+
+		if postgres && concurrentlyPattern.MatchString(code) && !parsed.NoTransaction {
+			issues = append(issues, newIssue("concurrent-index-in-transaction", SeverityError,
+				"CREATE INDEX CONCURRENTLY 不能在事务中执行，需要声明 -- +migrate NoTransaction", stmt))
+		}
+
+		for _, rule := range lintRules {
+			if rule.Postgres && !postgres {
+				continue
+			}
+			if rule.Match(code) {
+				issues = append(issues, newIssue(rule.Name, rule.Severity, rule.Message, stmt))
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// stripSQLComments 去除语句中的行注释并合并为单行，便于匹配
+func stripSQLComments(stmt string) string {
+	lines := strings.Split(stmt, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// HasLintSeverity 检查结果中是否包含不低于指定严重程度的问题
+func HasLintSeverity(issues []LintIssue, severity LintSeverity) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError || issue.Severity == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatLintIssues 格式化检查结果
+func FormatLintIssues(issues []LintIssue, format string) (string, error) {
+	switch format {
+	case "json":
+		if issues == nil {
+			issues = []LintIssue{}
+		}
+		data, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("序列化检查结果失败: %w", err)
+		}
+		return string(data), nil
+	case "", "text":
+	default:
+		return "", fmt.Errorf("不支持的输出格式: %s", format)
+	}
+
+	if len(issues) == 0 {
+		return "未发现高风险操作", nil
+	}
+
+	var builder strings.Builder
+	errors, warnings := 0, 0
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+
+		builder.WriteString(fmt.Sprintf("[%s] %s (%s) %s: %s\n",
+			strings.ToUpper(string(issue.Severity)), issue.MigrationID, issue.Name, issue.Rule, issue.Message))
+		if issue.Statement != "" {
+			stmt := strings.ReplaceAll(issue.Statement, "\n", "\n    ")
+			builder.WriteString("    " + stmt + "\n")
+		}
+	}
+	builder.WriteString(fmt.Sprintf("\n共 %d 个错误，%d 个警告", errors, warnings))
+
+	return builder.String(), nil
+}
@@ -0,0 +1,101 @@
+package migrator
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// 测试迁移文件高风险操作检查
+func TestLintMigration(t *testing.T) {
+	cases := []struct {
+		name   string
+		driver string
+		body   string
+		rules  []string
+	}{
+		{"Drop Table", "sqlite", "DROP TABLE users;", []string{"drop-table"}},
+		{"Drop Column", "sqlite", "ALTER TABLE users DROP COLUMN name;", []string{"drop-column"}},
+		{"Drop Column Without Keyword", "mysql", "ALTER TABLE users DROP name;", []string{"drop-column"}},
+		{"Drop Constraint", "postgres", "ALTER TABLE users DROP CONSTRAINT users_name_key;", nil},
+		{"Drop Not Null", "postgres", "ALTER TABLE users ALTER COLUMN name DROP NOT NULL;", nil},
+		{"Rename Column", "postgres", "ALTER TABLE users RENAME COLUMN name TO full_name;", []string{"rename-column"}},
+		{"MySQL Change Column", "mysql", "ALTER TABLE users CHANGE name full_name VARCHAR(255);", []string{"rename-column"}},
+		{"Not Null Without Default", "sqlite", "ALTER TABLE users ADD COLUMN age INTEGER NOT NULL;", []string{"not-null-without-default"}},
+		{"Not Null With Type Arguments", "postgres", "ALTER TABLE orders ADD COLUMN price NUMERIC(10,2) NOT NULL;", []string{"not-null-without-default"}},
+		{"Multiple Add Columns", "postgres", "ALTER TABLE orders ADD COLUMN note TEXT, ADD COLUMN price NUMERIC(10,2) NOT NULL;", []string{"not-null-without-default"}},
+		{"Not Null With Default", "sqlite", "ALTER TABLE users ADD COLUMN age INTEGER NOT NULL DEFAULT 0;", nil},
+		{"Create Table Not Null", "sqlite", "CREATE TABLE t (id INTEGER NOT NULL);", nil},
+		{"Index On Postgres", "postgres", "CREATE INDEX idx_users_name ON users (name);", []string{"non-concurrent-index"}},
+		{"Index On MySQL", "mysql", "CREATE INDEX idx_users_name ON users (name);", nil},
+		{"Concurrent Index In Transaction", "postgres", "CREATE INDEX CONCURRENTLY idx ON users (name);", []string{"concurrent-index-in-transaction"}},
+		{"Comment Only", "sqlite", "-- DROP TABLE users\nSELECT 1;", nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTestMigrator(t)
+			m.dbDriver = tc.driver
+			writeMigration(t, m, "20240101000000_change.sql", "-- +migrate Up\n"+tc.body+"\n\n-- +migrate Down\nSELECT 1;\n")
+
+			issues, err := m.Lint(true)
+			if err != nil {
+				t.Fatalf("检查失败: %v", err)
+			}
+
+			var rules []string
+			for _, issue := range issues {
+				rules = append(rules, issue.Rule)
+			}
+			if len(rules) != len(tc.rules) {
+				t.Fatalf("应报告 %v，实际为 %v", tc.rules, rules)
+			}
+			for i := range rules {
+				if rules[i] != tc.rules[i] {
+					t.Errorf("应报告 %v，实际为 %v", tc.rules, rules)
+				}
+			}
+		})
+	}
+
+	t.Run("Missing Down", func(t *testing.T) {
+		m := newTestMigrator(t)
+		writeMigration(t, m, "20240101000000_create_users.sql", "-- +migrate Up\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n")
+
+		issues, err := m.Lint(true)
+		if err != nil {
+			t.Fatalf("检查失败: %v", err)
+		}
+		if len(issues) != 1 || issues[0].Rule != "missing-down" || HasLintSeverity(issues, SeverityError) {
+			t.Errorf("缺少Down部分应报告警告: %+v", issues)
+		}
+	})
+
+	t.Run("Pending Only", func(t *testing.T) {
+		m := newTestMigrator(t)
+		writeMigration(t, m, "20240101000000_create_users.sql", "-- +migrate Up\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n")
+		if err := m.Up(0); err != nil {
+			t.Fatalf("向上迁移失败: %v", err)
+		}
+		writeMigration(t, m, "20240102000000_drop_users.sql", "-- +migrate Up\nDROP TABLE users;\n\n-- +migrate Down\nSELECT 1;\n")
+
+		issues, err := m.Lint(false)
+		if err != nil {
+			t.Fatalf("检查失败: %v", err)
+		}
+		if len(issues) != 1 || issues[0].MigrationID != "20240102000000" {
+			t.Errorf("应只检查待执行的迁移: %+v", issues)
+		}
+		if !HasLintSeverity(issues, SeverityError) {
+			t.Errorf("删除表应为错误级别")
+		}
+
+		out, err := FormatLintIssues(issues, "json")
+		if err != nil {
+			t.Fatalf("格式化失败: %v", err)
+		}
+		var decoded []LintIssue
+		if err := json.Unmarshal([]byte(out), &decoded); err != nil || len(decoded) != 1 {
+			t.Errorf("JSON输出不正确: %v\n%s", err, out)
+		}
+	})
+}
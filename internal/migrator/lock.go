@@ -0,0 +1,253 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"time"
+
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// lockRetryInterval 轮询获取迁移锁的间隔
+const lockRetryInterval = 500 * time.Millisecond
+
+// defaultLockTimeout 默认等待迁移锁的时间
+const defaultLockTimeout = 60 * time.Second
+
+// ErrLockTimeout 等待迁移锁超时
+var ErrLockTimeout = errors.New("等待迁移锁超时，可能有其他实例正在执行迁移；如确认锁已失效，可执行 migrate unlock 清除")
+
+// withLock 在持有迁移锁期间执行函数，避免多个实例同时执行迁移
+func (m *StandardMigrator) withLock(ctx context.Context, fn func() error) error {
+	if err := m.openDB(ctx); err != nil {
+		return err
+	}
+
+	// dry-run模式下不修改数据库，无需加锁
+	if m.dryRun != nil {
+		return fn()
+	}
+
+	timeout := m.lockTimeout
+	if timeout <= 0 {
+		timeout = defaultLockTimeout
+	}
+
+	logger.Debug("获取迁移锁 (超时: %s)", timeout)
+	release, err := m.dialect.Lock(ctx, m.db, m.schemaTable, timeout)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := release(); err != nil {
+			logger.Warn("释放迁移锁失败: %v", err)
+		}
+	}()
+
+	return fn()
+}
+
+// SetLockTimeout 设置等待迁移锁的超时时间
+func (m *StandardMigrator) SetLockTimeout(timeout time.Duration) {
+	m.lockTimeout = timeout
+}
+
+// Unlock 强制清除残留的迁移锁
+func (m *StandardMigrator) Unlock() error {
+	ctx := context.Background()
+	if err := m.openDB(ctx); err != nil {
+		return err
+	}
+
+	if err := m.dialect.ForceUnlock(ctx, m.db, m.schemaTable); err != nil {
+		return fmt.Errorf("清除迁移锁失败: %w", err)
+	}
+
+	logger.Info("已清除迁移锁")
+	return nil
+}
+
+// advisoryLockKey 将锁名称转换为PostgreSQL advisory lock使用的整数键
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("parkercli:" + name))
+	return int64(h.Sum64() & 0x7fffffffffffffff)
+}
+
+// lockOwner 返回当前进程的标识，记录在锁表中便于排查
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// pollLock 在超时前反复尝试获取锁
+func pollLock(ctx context.Context, timeout time.Duration, try func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := try()
+		if err != nil {
+			return fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+		if ok {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+// Lock 使用 pg_try_advisory_lock 获取会话级别的advisory lock
+func (postgresDialect) Lock(ctx context.Context, db *sql.DB, name string, timeout time.Duration) (func() error, error) {
+	key := advisoryLockKey(name)
+
+	// advisory lock 与会话绑定，加锁和解锁必须使用同一连接
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+
+	err = pollLock(ctx, timeout, func() (bool, error) {
+		var locked bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked)
+		return locked, err
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+		return err
+	}, nil
+}
+
+// ForceUnlock 终止持有advisory lock的会话
+func (postgresDialect) ForceUnlock(ctx context.Context, db *sql.DB, name string) error {
+	_, err := db.ExecContext(ctx, `SELECT pg_terminate_backend(pid) FROM pg_locks
+WHERE locktype = 'advisory' AND ((classid::bigint << 32) | objid::bigint) = $1`, advisoryLockKey(name))
+	return err
+}
+
+// Lock 使用 GET_LOCK 获取命名锁
+func (mysqlDialect) Lock(ctx context.Context, db *sql.DB, name string, timeout time.Duration) (func() error, error) {
+	lockName := "parkercli:" + name
+
+	// 命名锁与连接绑定，加锁和解锁必须使用同一连接
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+
+	var result sql.NullInt64
+	seconds := int(timeout.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, seconds).Scan(&result); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	if !result.Valid || result.Int64 != 1 {
+		conn.Close()
+		return nil, ErrLockTimeout
+	}
+
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+		return err
+	}, nil
+}
+
+// ForceUnlock 终止持有命名锁的连接
+func (mysqlDialect) ForceUnlock(ctx context.Context, db *sql.DB, name string) error {
+	var connID sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?)", "parkercli:"+name).Scan(&connID); err != nil {
+		return err
+	}
+	if !connID.Valid {
+		return nil
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf("KILL %d", connID.Int64))
+	return err
+}
+
+// sqliteLockTable 返回SQLite锁表名称
+func sqliteLockTable(name string) string {
+	return name + "_lock"
+}
+
+// ensureSQLiteLockTable 创建SQLite锁表(如不存在)
+func ensureSQLiteLockTable(ctx context.Context, db *sql.DB, table string) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    id INTEGER NOT NULL PRIMARY KEY,
+    locked_at TIMESTAMP NOT NULL,
+    owner VARCHAR(255) NOT NULL
+)`, table)
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("创建迁移锁表失败: %w", err)
+	}
+	return nil
+}
+
+// Lock 通过向锁表插入固定主键的记录获取锁
+func (sqliteDialect) Lock(ctx context.Context, db *sql.DB, name string, timeout time.Duration) (func() error, error) {
+	table := sqliteLockTable(name)
+	if err := ensureSQLiteLockTable(ctx, db, table); err != nil {
+		return nil, err
+	}
+
+	owner := lockOwner()
+	insertQuery := fmt.Sprintf("INSERT INTO %s (id, locked_at, owner) VALUES (1, ?, ?)", table)
+
+	err := pollLock(ctx, timeout, func() (bool, error) {
+		if _, err := db.ExecContext(ctx, insertQuery, time.Now().UTC(), owner); err != nil {
+			// 主键冲突说明锁已被占用
+			var exists int
+			if qErr := db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&exists); qErr == nil && exists > 0 {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		_, err := db.ExecContext(context.Background(),
+			fmt.Sprintf("DELETE FROM %s WHERE id = 1 AND owner = ?", table), owner)
+		return err
+	}, nil
+}
+
+// ForceUnlock 删除锁表中的记录
+func (sqliteDialect) ForceUnlock(ctx context.Context, db *sql.DB, name string) error {
+	table := sqliteLockTable(name)
+	if err := ensureSQLiteLockTable(ctx, db, table); err != nil {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", table))
+	return err
+}
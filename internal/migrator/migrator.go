@@ -1,7 +1,13 @@
 package migrator
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -9,7 +15,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/parker/ParkerCli/internal/config"
 	"github.com/parker/ParkerCli/internal/utils"
 	"github.com/parker/ParkerCli/pkg/logger"
 )
@@ -24,6 +29,8 @@ const (
 	StatusApplied MigrationStatus = "APPLIED"
 	// StatusFailed 表示执行失败
 	StatusFailed MigrationStatus = "FAILED"
+	// StatusMissing 表示已应用但迁移文件已被删除
+	StatusMissing MigrationStatus = "MISSING"
 )
 
 // MigrationType 表示迁移类型
@@ -46,6 +53,9 @@ type Migration struct {
 	AppliedAt  time.Time       // 应用时间
 	BatchID    int             // 批次ID
 	Reversible bool            // 是否可回滚
+	Checksum   string          // 迁移文件内容的SHA-256
+	Drifted    bool            // 已应用后文件内容是否被修改
+	Squashed   bool            // 是否为合并了之前所有迁移的基线迁移
 }
 
 // MigrationService 迁移服务接口
@@ -56,50 +66,97 @@ type MigrationService interface {
 	Status() ([]Migration, error)
 	Reset() error
 	Refresh() error
+	Fresh() error
+	Goto(version string) error
+	Redo() error
+	Baseline(version string) error
+	Unlock() error
+	Validate() ([]Migration, error)
+	Squash(until string) (*Migration, error)
 	Generate(name, template string) (*Migration, error)
+	Diff(opts DiffOptions) (*Migration, error)
 }
 
 // StandardMigrator 标准迁移器实现
 type StandardMigrator struct {
+	target        string // 数据库名称
 	migrationsDir string
+	source        fs.FS  // 迁移文件来源，为空时读取 migrationsDir
+	schemaFile    string // 迁移后写入数据库结构快照的文件，为空时不写入
 	schemaTable   string
+	seedsDir      string
+	seedTable     string
+	environment   string // 当前运行环境，用于筛选种子数据
 	dbDriver      string
 	dbDSN         string
+	db            *sql.DB
+	externalDB    bool // 数据库连接由调用方提供，Close时不关闭
+	dialect       dialect
+	runner        *goRunner
+	lockTimeout   time.Duration
+	dryRun        io.Writer // 非空时只输出要执行的SQL
+	outOfOrder    bool      // 是否允许乱序应用迁移
 }
 
-// NewStandardMigrator 创建新的标准迁移器
-func NewStandardMigrator() *StandardMigrator {
-	// 从配置中获取迁移目录
-	migrationsDir := config.GetString("paths.migrations")
-	if migrationsDir == "" {
-		migrationsDir = "./migrations"
-	}
-
-	// 数据库配置
-	dbConfig := config.GetAll().Database
-	dbDriver := dbConfig.Driver
+// DefaultTarget 使用 database 配置块的默认数据库名称
+const DefaultTarget = "default"
+
+// Options 创建迁移器的选项
+type Options struct {
+	Target          string        // 数据库名称，为空时为 default
+	Driver          string        // 数据库驱动: postgres、mysql 或 sqlite
+	DSN             string        // 数据库连接字符串
+	MigrationsDir   string        // 迁移目录，为空时为 ./migrations
+	SeedsDir        string        // 种子数据目录，为空时为 ./seeds
+	SchemaFile      string        // 迁移后写入数据库结构快照的文件，为空时不写入
+	Environment     string        // 当前运行环境，用于筛选种子数据
+	LockTimeout     time.Duration // 等待迁移锁的超时时间，为0时使用默认值
+	AllowOutOfOrder bool          // 是否允许乱序应用迁移
+}
 
-	var dbDSN string
-	if dbDriver == "postgres" {
-		dbDSN = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-			dbConfig.Host, dbConfig.Port, dbConfig.User, dbConfig.Password, dbConfig.Name, dbConfig.SSLMode)
-	} else if dbDriver == "mysql" {
-		dbDSN = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
-			dbConfig.User, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Name)
+// NewStandardMigrator 创建标准迁移器
+//
+// 迁移器不读取配置文件，也不导入数据库驱动，调用方需要导入所用数据库的驱动，
+// 如 _ "github.com/lib/pq"。按配置文件创建迁移器见 targets 包。
+func NewStandardMigrator(opts Options) *StandardMigrator {
+	if opts.Target == "" {
+		opts.Target = DefaultTarget
+	}
+	if opts.MigrationsDir == "" {
+		opts.MigrationsDir = "./migrations"
+	}
+	if opts.SeedsDir == "" {
+		opts.SeedsDir = "./seeds"
 	}
 
 	return &StandardMigrator{
-		migrationsDir: migrationsDir,
+		target:        opts.Target,
+		migrationsDir: opts.MigrationsDir,
+		schemaFile:    opts.SchemaFile,
 		schemaTable:   "schema_migrations",
-		dbDriver:      dbDriver,
-		dbDSN:         dbDSN,
+		seedsDir:      opts.SeedsDir,
+		seedTable:     "schema_seeds",
+		environment:   opts.Environment,
+		dbDriver:      opts.Driver,
+		dbDSN:         opts.DSN,
+		lockTimeout:   opts.LockTimeout,
+		outOfOrder:    opts.AllowOutOfOrder,
 	}
 }
 
+// Target 返回迁移器使用的数据库名称
+func (m *StandardMigrator) Target() string {
+	return m.target
+}
+
 // Create 创建新迁移
 func (m *StandardMigrator) Create(name string, migrationType MigrationType) (*Migration, error) {
 	logger.Info("创建新迁移: %s", name)
 
+	if err := m.writableSource(); err != nil {
+		return nil, err
+	}
+
 	// 确保迁移目录存在
 	if err := utils.EnsureDir(m.migrationsDir); err != nil {
 		return nil, fmt.Errorf("创建迁移目录失败: %w", err)
@@ -155,7 +212,6 @@ DROP TABLE IF EXISTS example;
 import (
 	"context"
 	"database/sql"
-	"time"
 )
 
 // %s 迁移函数
@@ -204,49 +260,59 @@ func Down_%s(ctx context.Context, db *sql.DB) error {
 // findMigrations 查找所有迁移文件
 func (m *StandardMigrator) findMigrations() ([]Migration, error) {
 	// 确保迁移目录存在
-	if err := utils.EnsureDir(m.migrationsDir); err != nil {
-		return nil, fmt.Errorf("检查迁移目录失败: %w", err)
+	if m.source == nil {
+		if err := utils.EnsureDir(m.migrationsDir); err != nil {
+			return nil, fmt.Errorf("检查迁移目录失败: %w", err)
+		}
 	}
 
 	// 读取目录内容
-	entries, err := os.ReadDir(m.migrationsDir)
+	source := m.migrationSource()
+	entries, err := fs.ReadDir(source, ".")
 	if err != nil {
 		return nil, fmt.Errorf("读取迁移目录失败: %w", err)
 	}
 
 	// 解析迁移文件
 	var migrations []Migration
+	seen := make(map[string]string)
 	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
 		fileName := entry.Name()
-		parts := strings.SplitN(fileName, "_", 2)
-		if len(parts) != 2 {
+		// 忽略目录和 .gitkeep 等隐藏文件
+		if entry.IsDir() || strings.HasPrefix(fileName, ".") {
 			continue
 		}
 
-		id := parts[0]
-		if len(id) != 14 { // 通常是年月日时分秒 (14位)
+		id, name, migrationType, ok := parseMigrationFileName(fileName)
+		if !ok {
+			logger.Warn("跳过文件名格式不正确的文件: %s (应为 <14位时间戳>_<名称>.sql 或 .go)", fileName)
 			continue
 		}
 
-		nameParts := strings.Split(parts[1], ".")
-		if len(nameParts) != 2 {
-			continue
+		if other, ok := seen[id]; ok {
+			return nil, fmt.Errorf("迁移ID重复: %s (%s 与 %s)", id, other, fileName)
 		}
+		seen[id] = fileName
 
-		name := nameParts[0]
-		ext := nameParts[1]
+		filePath := m.migrationFilePath(fileName)
 
-		var migrationType MigrationType
-		if ext == "sql" {
-			migrationType = TypeSQL
-		} else if ext == "go" {
-			migrationType = TypeGoFn
-		} else {
-			continue
+		content, err := fs.ReadFile(source, fileName)
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+		}
+		checksum := contentChecksum(content)
+
+		// SQL迁移没有Down部分时不可回滚
+		reversible, squashed := true, false
+		if migrationType == TypeSQL {
+			parsed, err := parseMigrationContent(filePath, content)
+			if err != nil {
+				return nil, err
+			}
+			reversible = parsed.HasDown
+			squashed = parsed.Squashed
+		} else if fns, ok := lookupGoMigration(id); ok {
+			reversible = fns.Down != nil
 		}
 
 		migrations = append(migrations, Migration{
@@ -254,8 +320,10 @@ func (m *StandardMigrator) findMigrations() ([]Migration, error) {
 			Name:       name,
 			Type:       migrationType,
 			Status:     StatusPending, // 默认为待执行
-			FilePath:   filepath.Join(m.migrationsDir, fileName),
-			Reversible: true,
+			FilePath:   filePath,
+			Reversible: reversible,
+			Checksum:   checksum,
+			Squashed:   squashed,
 		})
 	}
 
@@ -267,128 +335,261 @@ func (m *StandardMigrator) findMigrations() ([]Migration, error) {
 	return migrations, nil
 }
 
-// Up 执行向上迁移
-func (m *StandardMigrator) Up(steps int) error {
-	logger.Info("执行向上迁移, 步数: %d", steps)
-
-	// 获取所有迁移
-	migrations, err := m.findMigrations()
-	if err != nil {
-		return err
+// parseMigrationFileName 解析 {id}_{name}.{sql|go} 格式的迁移文件名
+func parseMigrationFileName(fileName string) (id, name string, migrationType MigrationType, ok bool) {
+	parts := strings.SplitN(fileName, "_", 2)
+	if len(parts) != 2 {
+		return "", "", "", false
 	}
 
-	if len(migrations) == 0 {
-		logger.Info("没有找到迁移文件")
-		return nil
+	// ID通常是年月日时分秒 (14位数字)
+	id = parts[0]
+	if len(id) != 14 {
+		return "", "", "", false
+	}
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return "", "", "", false
 	}
 
-	// TODO: 连接数据库并检查已应用迁移
-	// 这里为演示简化处理，实际应查询schemaTable表获取已应用迁移状态
-
-	// 计算要应用的迁移数
-	applyCount := len(migrations)
-	if steps > 0 && steps < applyCount {
-		applyCount = steps
+	nameParts := strings.Split(parts[1], ".")
+	if len(nameParts) != 2 || nameParts[0] == "" {
+		return "", "", "", false
 	}
 
-	// 模拟应用迁移
-	for i := 0; i < applyCount; i++ {
-		logger.Info("应用迁移: %s (%s)", migrations[i].Name, migrations[i].ID)
-		// TODO: 实际执行迁移SQL或Go函数
+	switch nameParts[1] {
+	case "sql":
+		migrationType = TypeSQL
+	case "go":
+		migrationType = TypeGoFn
+	default:
+		return "", "", "", false
 	}
 
-	logger.Info("成功应用 %d 个迁移", applyCount)
-	return nil
+	return id, nameParts[0], migrationType, true
+}
+
+// SetAllowOutOfOrder 设置是否允许应用早于最新已应用迁移的待执行迁移
+func (m *StandardMigrator) SetAllowOutOfOrder(allow bool) {
+	m.outOfOrder = allow
+}
+
+// Up 执行向上迁移
+func (m *StandardMigrator) Up(steps int) error {
+	logger.Info("执行向上迁移, 步数: %d", steps)
+	return m.execute(context.Background(), planUp(steps))
 }
 
 // Down 执行向下迁移(回滚)
 func (m *StandardMigrator) Down(steps int) error {
 	logger.Info("执行向下迁移(回滚), 步数: %d", steps)
+	return m.execute(context.Background(), planDown(steps))
+}
 
-	// 获取所有迁移
-	migrations, err := m.findMigrations()
+// Status 获取迁移状态
+func (m *StandardMigrator) Status() ([]Migration, error) {
+	logger.Info("获取迁移状态")
+
+	migrations, err := m.loadMigrations(context.Background())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(migrations) == 0 {
 		logger.Info("没有找到迁移文件")
-		return nil
 	}
 
-	// TODO: 连接数据库并检查已应用迁移
-	// 这里为演示简化处理，实际应查询schemaTable表获取已应用迁移状态
+	return migrations, nil
+}
 
-	// 反转迁移顺序（回滚从最新到最旧）
-	sort.Slice(migrations, func(i, j int) bool {
-		return migrations[i].ID > migrations[j].ID
-	})
+// loadMigrations 查找迁移文件并结合数据库记录填充迁移状态
+func (m *StandardMigrator) loadMigrations(ctx context.Context) ([]Migration, error) {
+	migrations, err := m.findMigrations()
+	if err != nil {
+		return nil, err
+	}
 
-	// 计算要回滚的迁移数
-	rollbackCount := len(migrations)
-	if steps > 0 && steps < rollbackCount {
-		rollbackCount = steps
+	if err := m.openDB(ctx); err != nil {
+		return nil, err
 	}
 
-	// 模拟回滚迁移
-	for i := 0; i < rollbackCount; i++ {
-		logger.Info("回滚迁移: %s (%s)", migrations[i].Name, migrations[i].ID)
-		// TODO: 实际执行回滚SQL或Go函数
+	applied, err := m.loadApplied(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	logger.Info("成功回滚 %d 个迁移", rollbackCount)
-	return nil
+	// dry-run模式下不修改迁移记录
+	if m.dryRun == nil {
+		if err := m.syncSquashed(ctx, migrations, applied); err != nil {
+			return nil, err
+		}
+	}
+
+	found := make(map[string]bool, len(migrations))
+	for i := range migrations {
+		found[migrations[i].ID] = true
+		if record, ok := applied[migrations[i].ID]; ok {
+			migrations[i].Status = record.Status
+			migrations[i].AppliedAt = record.AppliedAt
+			migrations[i].BatchID = record.Batch
+
+			// 早期版本写入的记录没有校验和，无法判断是否被修改
+			if record.Status == StatusApplied && record.Checksum != "" && record.Checksum != migrations[i].Checksum {
+				migrations[i].Drifted = true
+			}
+		}
+	}
+
+	// 已应用但文件已被删除的迁移
+	missing := false
+	for _, record := range applied {
+		if found[record.Version] || record.Status != StatusApplied {
+			continue
+		}
+		missing = true
+		migrations = append(migrations, Migration{
+			ID:        record.Version,
+			Name:      record.Name,
+			Status:    StatusMissing,
+			AppliedAt: record.AppliedAt,
+			BatchID:   record.Batch,
+			Checksum:  record.Checksum,
+		})
+	}
+
+	if missing {
+		sort.Slice(migrations, func(i, j int) bool {
+			return migrations[i].ID < migrations[j].ID
+		})
+	}
+
+	return migrations, nil
 }
 
-// Status 获取迁移状态
-func (m *StandardMigrator) Status() ([]Migration, error) {
-	logger.Info("获取迁移状态")
+// Validate 校验已应用迁移的文件是否被修改或删除，返回存在问题的迁移
+func (m *StandardMigrator) Validate() ([]Migration, error) {
+	logger.Info("校验已应用迁移")
 
-	// 获取所有迁移
-	migrations, err := m.findMigrations()
+	migrations, err := m.loadMigrations(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	if len(migrations) == 0 {
-		logger.Info("没有找到迁移文件")
-		return migrations, nil
+	var problems []Migration
+	for _, migration := range migrations {
+		if migration.Drifted || migration.Status == StatusMissing {
+			problems = append(problems, migration)
+		}
 	}
 
-	// TODO: 连接数据库并检查已应用迁移
-	// 这里为演示简化处理，实际应查询schemaTable表获取已应用迁移状态
-	// 假设前3个已应用
-	appliedCount := min(3, len(migrations))
-	for i := 0; i < appliedCount; i++ {
-		migrations[i].Status = StatusApplied
-		migrations[i].AppliedAt = time.Now().Add(-time.Duration(i) * 24 * time.Hour)
-		migrations[i].BatchID = 1
+	return problems, nil
+}
+
+// fileChecksum 计算文件内容的SHA-256
+func fileChecksum(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("读取迁移文件失败: %w", err)
 	}
+	return contentChecksum(content), nil
+}
 
-	return migrations, nil
+// contentChecksum 计算内容的SHA-256
+func contentChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
 }
 
-// Reset 重置所有迁移
+// Reset 按批次倒序回滚所有已应用的迁移
 func (m *StandardMigrator) Reset() error {
 	logger.Info("重置所有迁移")
 
-	// 先全部回滚
-	if err := m.Down(0); err != nil {
-		return fmt.Errorf("回滚迁移失败: %w", err)
-	}
-
-	// 再全部应用
-	if err := m.Up(0); err != nil {
-		return fmt.Errorf("应用迁移失败: %w", err)
+	if err := m.execute(context.Background(), planDown(0)); err != nil {
+		return fmt.Errorf("重置迁移失败: %w", err)
 	}
 
 	logger.Info("成功重置所有迁移")
 	return nil
 }
 
-// Refresh 刷新所有迁移
+// Refresh 回滚所有已应用的迁移后重新应用全部迁移
 func (m *StandardMigrator) Refresh() error {
-	return m.Reset()
+	logger.Info("刷新所有迁移")
+
+	if err := m.execute(context.Background(), planDown(0), planUp(0)); err != nil {
+		return fmt.Errorf("刷新迁移失败: %w", err)
+	}
+
+	logger.Info("成功刷新所有迁移")
+	return nil
+}
+
+// Fresh 删除数据库中的所有表后重新应用全部迁移
+//
+// 与Refresh不同，Fresh不执行迁移的Down部分，适用于Down部分缺失或不可靠的情况。
+func (m *StandardMigrator) Fresh() error {
+	logger.Info("删除所有表并重新应用迁移")
+
+	if m.dryRun != nil {
+		return fmt.Errorf("fresh 不支持 dry-run 模式")
+	}
+
+	ctx := context.Background()
+	err := m.withLock(ctx, func() error {
+		if err := m.dropAllTables(ctx); err != nil {
+			return err
+		}
+		return m.executeLocked(ctx, planUp(0))
+	})
+	if err != nil {
+		return fmt.Errorf("重建数据库失败: %w", err)
+	}
+
+	logger.Info("成功重建数据库")
+	return nil
+}
+
+// dropAllTables 删除数据库中除迁移锁表外的所有表，并重新创建迁移记录表
+func (m *StandardMigrator) dropAllTables(ctx context.Context) error {
+	tables, err := m.dialect.Tables(ctx, m.db)
+	if err != nil {
+		return fmt.Errorf("查询数据库表失败: %w", err)
+	}
+
+	// SQLite的迁移锁保存在锁表中，不能删除
+	lockTable := sqliteLockTable(m.schemaTable)
+	var drop []string
+	for _, table := range tables {
+		if table != lockTable {
+			drop = append(drop, table)
+		}
+	}
+
+	if len(drop) > 0 {
+		logger.Info("删除 %d 个表: %s", len(drop), strings.Join(drop, ", "))
+		if err := m.dialect.DropTables(ctx, m.db, drop); err != nil {
+			return fmt.Errorf("删除数据库表失败: %w", err)
+		}
+	}
+
+	return m.ensureSchemaTable(ctx)
+}
+
+// Goto 迁移到指定版本，回滚之后的迁移并应用之前的待执行迁移
+func (m *StandardMigrator) Goto(version string) error {
+	logger.Info("迁移到版本: %s", version)
+	return m.execute(context.Background(), planGoto(version))
+}
+
+// Redo 回滚并重新应用最后一个批次的迁移
+func (m *StandardMigrator) Redo() error {
+	logger.Info("重新执行最后一个批次的迁移")
+	return m.execute(context.Background(), planRedo())
+}
+
+// Baseline 将指定版本及之前的迁移标记为已应用而不执行，用于接入已有数据库
+func (m *StandardMigrator) Baseline(version string) error {
+	logger.Info("设置迁移基线: %s", version)
+	return m.execute(context.Background(), planBaseline(version))
 }
 
 // Generate 生成新迁移
@@ -438,12 +639,9 @@ func FormatMigrationStatus(migrations []Migration) string {
 
 	for _, m := range migrations {
 		appliedAt := ""
-		if m.Status == StatusApplied {
-			appliedAt = m.AppliedAt.Format("2006-01-02 15:04:05")
-		}
-
 		batchID := ""
-		if m.Status == StatusApplied {
+		if m.Status != StatusPending {
+			appliedAt = m.AppliedAt.Format("2006-01-02 15:04:05")
 			batchID = strconv.Itoa(m.BatchID)
 		}
 
@@ -451,13 +649,54 @@ func FormatMigrationStatus(migrations []Migration) string {
 			m.ID, m.Name, m.Type, m.Status, appliedAt, batchID))
 	}
 
+	// 提示校验和不一致和文件被删除的迁移
+	for _, m := range migrations {
+		if m.Drifted {
+			builder.WriteString(fmt.Sprintf("\n警告: 迁移 %s (%s) 在应用后被修改", m.ID, m.Name))
+		} else if m.Status == StatusMissing {
+			builder.WriteString(fmt.Sprintf("\n警告: 迁移 %s (%s) 已应用但文件已被删除", m.ID, m.Name))
+		}
+	}
+
 	return builder.String()
 }
 
-// min 返回两个int中较小的一个
-func min(a, b int) int {
-	if a < b {
-		return a
+// TargetStatus 表示一个数据库的迁移状态
+type TargetStatus struct {
+	Target     string      // 数据库名称
+	Migrations []Migration // 迁移状态
+	Err        error       // 获取状态失败时的错误
+}
+
+// FormatTargetStatus 将多个数据库的迁移状态格式化为一张表
+func FormatTargetStatus(targets []TargetStatus) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%-12s | %-14s | %-30s | %-8s | %-10s | %-19s | %s\n",
+		"数据库", "ID", "名称", "类型", "状态", "应用时间", "批次"))
+	builder.WriteString(strings.Repeat("-", 115) + "\n")
+
+	for _, t := range targets {
+		if t.Err != nil {
+			builder.WriteString(fmt.Sprintf("%-12s | 获取迁移状态失败: %v\n", t.Target, t.Err))
+			continue
+		}
+		if len(t.Migrations) == 0 {
+			builder.WriteString(fmt.Sprintf("%-12s | 没有发现迁移文件\n", t.Target))
+			continue
+		}
+
+		for _, m := range t.Migrations {
+			appliedAt := ""
+			batchID := ""
+			if m.Status != StatusPending {
+				appliedAt = m.AppliedAt.Format("2006-01-02 15:04:05")
+				batchID = strconv.Itoa(m.BatchID)
+			}
+
+			builder.WriteString(fmt.Sprintf("%-12s | %-14s | %-30s | %-8s | %-10s | %-19s | %s\n",
+				t.Target, m.ID, m.Name, m.Type, m.Status, appliedAt, batchID))
+		}
 	}
-	return b
+
+	return builder.String()
 }
@@ -0,0 +1,1073 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// 创建基于SQLite文件数据库的测试迁移器
+func newTestMigrator(t *testing.T) *StandardMigrator {
+	dir := t.TempDir()
+
+	m := &StandardMigrator{
+		migrationsDir: filepath.Join(dir, "migrations"),
+		schemaTable:   "schema_migrations",
+		seedsDir:      filepath.Join(dir, "seeds"),
+		seedTable:     "schema_seeds",
+		environment:   "testing",
+		dbDriver:      "sqlite",
+		dbDSN:         filepath.Join(dir, "test.db") + "?_pragma=busy_timeout(5000)",
+	}
+
+	if err := os.MkdirAll(m.migrationsDir, 0755); err != nil {
+		t.Fatalf("创建迁移目录失败: %v", err)
+	}
+
+	t.Cleanup(func() {
+		m.Close()
+	})
+
+	return m
+}
+
+// 写入测试迁移文件
+func writeMigration(t *testing.T, m *StandardMigrator, fileName, content string) {
+	if err := os.WriteFile(filepath.Join(m.migrationsDir, fileName), []byte(content), 0644); err != nil {
+		t.Fatalf("写入迁移文件失败: %v", err)
+	}
+}
+
+// 统计指定状态的迁移数量
+func countStatus(migrations []Migration, status MigrationStatus) int {
+	count := 0
+	for _, migration := range migrations {
+		if migration.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// 测试向上和向下迁移
+func TestUpDown(t *testing.T) {
+	m := newTestMigrator(t)
+
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+	writeMigration(t, m, "20240102000000_create_posts.sql", `-- +migrate Up
+CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT);
+
+-- +migrate Down
+DROP TABLE posts;
+`)
+
+	t.Run("Up All", func(t *testing.T) {
+		if err := m.Up(0); err != nil {
+			t.Fatalf("向上迁移失败: %v", err)
+		}
+
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+
+		if countStatus(migrations, StatusApplied) != 2 {
+			t.Errorf("应有2个已应用迁移，实际为%d", countStatus(migrations, StatusApplied))
+		}
+
+		if _, err := m.db.Exec("INSERT INTO posts (title) VALUES ('hello')"); err != nil {
+			t.Errorf("posts表应已创建: %v", err)
+		}
+	})
+
+	t.Run("Up Again Is No-op", func(t *testing.T) {
+		if err := m.Up(0); err != nil {
+			t.Fatalf("重复向上迁移失败: %v", err)
+		}
+	})
+
+	t.Run("Down One Step", func(t *testing.T) {
+		if err := m.Down(1); err != nil {
+			t.Fatalf("回滚失败: %v", err)
+		}
+
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+
+		if migrations[0].Status != StatusApplied || migrations[1].Status != StatusPending {
+			t.Errorf("回滚后状态不正确: %s, %s", migrations[0].Status, migrations[1].Status)
+		}
+
+		if _, err := m.db.Exec("SELECT 1 FROM posts"); err == nil {
+			t.Errorf("posts表应已被删除")
+		}
+	})
+
+	t.Run("Batch Increments", func(t *testing.T) {
+		if err := m.Up(0); err != nil {
+			t.Fatalf("向上迁移失败: %v", err)
+		}
+
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+
+		if migrations[0].BatchID != 1 || migrations[1].BatchID != 2 {
+			t.Errorf("批次不正确: %d, %d", migrations[0].BatchID, migrations[1].BatchID)
+		}
+	})
+}
+
+// 测试没有Down部分的迁移拒绝回滚
+func TestDownIrreversible(t *testing.T) {
+	m := newTestMigrator(t)
+
+	writeMigration(t, m, "20240101000000_seed_data.sql", `-- +migrate Up
+CREATE TABLE settings (k TEXT PRIMARY KEY, v TEXT);
+INSERT INTO settings (k, v) VALUES ('a', '1');
+`)
+
+	if err := m.Up(0); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	migrations, err := m.Status()
+	if err != nil {
+		t.Fatalf("获取迁移状态失败: %v", err)
+	}
+	if migrations[0].Reversible {
+		t.Errorf("没有Down部分的迁移应标记为不可回滚")
+	}
+
+	if err := m.Down(1); err == nil {
+		t.Errorf("回滚不可回滚的迁移应返回错误")
+	}
+
+	var count int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM settings").Scan(&count); err != nil || count != 1 {
+		t.Errorf("拒绝回滚后数据应保持不变: count=%d err=%v", count, err)
+	}
+}
+
+// 测试注册的Go函数迁移与SQL迁移按ID顺序交替执行
+func TestGoMigrationsInterleaved(t *testing.T) {
+	m := newTestMigrator(t)
+
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+	writeMigration(t, m, "20240102000000_seed_users.go", "package migrations\n")
+	writeMigration(t, m, "20240103000000_add_email.sql", `-- +migrate Up
+ALTER TABLE users ADD COLUMN email TEXT;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN email;
+`)
+
+	Register("20240102000000",
+		func(ctx context.Context, db *sql.DB) error {
+			_, err := db.ExecContext(ctx, "INSERT INTO users (name) VALUES ('parker')")
+			return err
+		},
+		func(ctx context.Context, db *sql.DB) error {
+			_, err := db.ExecContext(ctx, "DELETE FROM users")
+			return err
+		})
+
+	if err := m.Up(0); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	var count int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM users WHERE email IS NULL").Scan(&count); err != nil || count != 1 {
+		t.Errorf("Go迁移应在SQL迁移之间执行: count=%d err=%v", count, err)
+	}
+
+	if err := m.Down(2); err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 0 {
+		t.Errorf("Go迁移的Down函数应已执行: count=%d err=%v", count, err)
+	}
+}
+
+// 测试未注册的Go迁移通过在项目模块中生成的运行器执行
+func TestGoMigrationsRunner(t *testing.T) {
+	if testing.Short() {
+		t.Skip("需要编译运行器")
+	}
+
+	// 临时模块使用与本项目相同的依赖版本，无需下载SQLite驱动
+	goMod, err := os.ReadFile(filepath.Join("..", "..", "go.mod"))
+	if err != nil {
+		t.Fatalf("读取go.mod失败: %v", err)
+	}
+	goSum, err := os.ReadFile(filepath.Join("..", "..", "go.sum"))
+	if err != nil {
+		t.Fatalf("读取go.sum失败: %v", err)
+	}
+	_, requires, _ := strings.Cut(string(goMod), "\n")
+
+	moduleDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte("module example.com/app\n"+requires), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(moduleDir, "go.sum"), goSum, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestMigrator(t)
+	m.migrationsDir = filepath.Join(moduleDir, "db", "migrations")
+	if err := os.MkdirAll(m.migrationsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+	// 使用其他测试没有通过 Register 注册的ID，确保经由运行器执行
+	writeMigration(t, m, "20240102120000_seed_users.go", `package migrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+func Up_20240102120000(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "INSERT INTO users (name) VALUES ('runner')")
+	return err
+}
+
+func Down_20240102120000(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM users WHERE name = 'runner'")
+	return err
+}
+`)
+	writeMigration(t, m, "20240103000000_add_email.sql", `-- +migrate Up
+ALTER TABLE users ADD COLUMN email TEXT;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN email;
+`)
+
+	if err := m.Up(0); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	// Go迁移在添加email列之前执行
+	var count int
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM users WHERE name = 'runner' AND email IS NULL").Scan(&count); err != nil || count != 1 {
+		t.Errorf("Go迁移应在SQL迁移之间执行: count=%d err=%v", count, err)
+	}
+
+	// 运行器源码目录在编译后删除，不会留在项目中
+	entries, err := os.ReadDir(moduleDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "_parkercli_runner_") {
+			t.Errorf("运行器源码目录未删除: %s", entry.Name())
+		}
+	}
+
+	if err := m.Down(2); err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+	if err := m.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 0 {
+		t.Errorf("Go迁移的Down函数应已执行: count=%d err=%v", count, err)
+	}
+
+	// 关闭迁移器时删除运行器二进制
+	if m.runner == nil {
+		t.Fatal("应已编译运行器")
+	}
+	binDir := m.runner.tmpDir
+	m.Close()
+	if _, err := os.Stat(binDir); !os.IsNotExist(err) {
+		t.Errorf("运行器临时目录未删除: %s", binDir)
+	}
+}
+
+// 测试迁移失败时事务回滚并记录失败状态
+func TestFailedMigrationRollsBack(t *testing.T) {
+	m := newTestMigrator(t)
+
+	writeMigration(t, m, "20240101000000_broken.sql", `-- +migrate Up
+CREATE TABLE half_done (id INTEGER PRIMARY KEY);
+INSERT INTO missing_table (id) VALUES (1);
+
+-- +migrate Down
+DROP TABLE half_done;
+`)
+
+	if err := m.Up(0); err == nil {
+		t.Fatalf("执行失败的迁移应返回错误")
+	}
+
+	if _, err := m.db.Exec("SELECT 1 FROM half_done"); err == nil {
+		t.Errorf("事务回滚后 half_done 表不应存在")
+	}
+
+	migrations, err := m.Status()
+	if err != nil {
+		t.Fatalf("获取迁移状态失败: %v", err)
+	}
+	if migrations[0].Status != StatusFailed {
+		t.Errorf("迁移状态应为 FAILED，实际为 %s", migrations[0].Status)
+	}
+
+	// 修复迁移文件后重新执行
+	writeMigration(t, m, "20240101000000_broken.sql", `-- +migrate Up
+CREATE TABLE half_done (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE half_done;
+`)
+
+	if err := m.Up(0); err != nil {
+		t.Fatalf("修复后重新执行迁移失败: %v", err)
+	}
+
+	migrations, err = m.Status()
+	if err != nil {
+		t.Fatalf("获取迁移状态失败: %v", err)
+	}
+	if migrations[0].Status != StatusApplied {
+		t.Errorf("迁移状态应为 APPLIED，实际为 %s", migrations[0].Status)
+	}
+}
+
+// 测试 NoTransaction 迁移失败时保留已执行的语句
+func TestNoTransactionMigration(t *testing.T) {
+	m := newTestMigrator(t)
+
+	writeMigration(t, m, "20240101000000_no_tx.sql", `-- +migrate NoTransaction
+-- +migrate Up
+CREATE TABLE outside_tx (id INTEGER PRIMARY KEY);
+INSERT INTO missing_table (id) VALUES (1);
+`)
+
+	if err := m.Up(0); err == nil {
+		t.Fatalf("执行失败的迁移应返回错误")
+	}
+
+	if _, err := m.db.Exec("SELECT 1 FROM outside_tx"); err != nil {
+		t.Errorf("不使用事务时已执行的语句应保留: %v", err)
+	}
+}
+
+// 测试迁移锁阻止并发执行，unlock 可清除残留锁
+func TestMigrationLock(t *testing.T) {
+	m := newTestMigrator(t)
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+
+	ctx := context.Background()
+	if err := m.openDB(ctx); err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+
+	// 模拟另一个实例持有锁后异常退出
+	if _, err := m.dialect.Lock(ctx, m.db, m.schemaTable, time.Second); err != nil {
+		t.Fatalf("获取迁移锁失败: %v", err)
+	}
+
+	m.SetLockTimeout(time.Second)
+	if err := m.Up(0); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("锁被占用时应返回 ErrLockTimeout，实际为: %v", err)
+	}
+
+	if err := m.Unlock(); err != nil {
+		t.Fatalf("清除迁移锁失败: %v", err)
+	}
+
+	if err := m.Up(0); err != nil {
+		t.Fatalf("清除锁后向上迁移失败: %v", err)
+	}
+}
+
+// 测试检测已应用迁移的文件修改和删除
+func TestChecksumDrift(t *testing.T) {
+	m := newTestMigrator(t)
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+	writeMigration(t, m, "20240102000000_create_posts.sql", `-- +migrate Up
+CREATE TABLE posts (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE posts;
+`)
+
+	if err := m.Up(0); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	problems, err := m.Validate()
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("未修改文件时不应报告问题: %v", problems)
+	}
+
+	// 修改第一个迁移，删除第二个迁移
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+	if err := os.Remove(filepath.Join(m.migrationsDir, "20240102000000_create_posts.sql")); err != nil {
+		t.Fatalf("删除迁移文件失败: %v", err)
+	}
+
+	migrations, err := m.Status()
+	if err != nil {
+		t.Fatalf("获取迁移状态失败: %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("应有2个迁移(含已删除的)，实际为%d", len(migrations))
+	}
+	if !migrations[0].Drifted {
+		t.Errorf("被修改的迁移应标记为 Drifted")
+	}
+	if migrations[1].Status != StatusMissing || migrations[1].Name != "create_posts" {
+		t.Errorf("被删除的迁移应为 MISSING: %+v", migrations[1])
+	}
+
+	problems, err = m.Validate()
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if len(problems) != 2 {
+		t.Errorf("应报告2个问题，实际为%d", len(problems))
+	}
+}
+
+// 测试dry-run模式只输出SQL而不修改数据库
+func TestDryRun(t *testing.T) {
+	m := newTestMigrator(t)
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+	writeMigration(t, m, "20240102000000_create_posts.sql", `-- +migrate Up
+CREATE TABLE posts (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE posts;
+`)
+
+	t.Run("Up Before Schema Table", func(t *testing.T) {
+		var buf strings.Builder
+		m.SetDryRun(&buf)
+		defer m.SetDryRun(nil)
+
+		if err := m.Up(0); err != nil {
+			t.Fatalf("dry-run向上迁移失败: %v", err)
+		}
+
+		out := buf.String()
+		for _, want := range []string{"CREATE TABLE users", "CREATE TABLE posts", "(批次: 1)", "BEGIN;"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("输出中缺少 %q:\n%s", want, out)
+			}
+		}
+
+		var count int
+		if err := m.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&count); err != nil {
+			t.Fatalf("查询数据库表失败: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("dry-run不应创建任何表，实际创建了%d个", count)
+		}
+	})
+
+	// 关闭连接以便后续以普通模式重新打开并创建迁移记录表
+	m.Close()
+
+	if err := m.Up(1); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	t.Run("Refresh", func(t *testing.T) {
+		var buf strings.Builder
+		m.SetDryRun(&buf)
+		defer m.SetDryRun(nil)
+
+		if err := m.Refresh(); err != nil {
+			t.Fatalf("dry-run刷新失败: %v", err)
+		}
+
+		out := buf.String()
+		down := strings.Index(out, "DOWN 20240101000000")
+		up := strings.Index(out, "UP 20240101000000")
+		if down < 0 || up < 0 || down > up {
+			t.Errorf("应先回滚再应用迁移:\n%s", out)
+		}
+		if !strings.Contains(out, "UP 20240102000000 create_posts (批次: 1)") {
+			t.Errorf("刷新后重新应用的迁移应属于批次1:\n%s", out)
+		}
+
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+		if countStatus(migrations, StatusApplied) != 1 {
+			t.Errorf("dry-run不应修改迁移状态")
+		}
+	})
+}
+
+// 测试迁移到指定版本、重新执行最后批次和设置基线
+func TestGotoRedoBaseline(t *testing.T) {
+	m := newTestMigrator(t)
+	for _, name := range []string{"20240101000000_create_a.sql", "20240102000000_create_b.sql", "20240103000000_create_c.sql"} {
+		table := strings.TrimSuffix(strings.SplitN(name, "_", 2)[1], ".sql")
+		writeMigration(t, m, name, "-- +migrate Up\nCREATE TABLE "+table+" (id INTEGER PRIMARY KEY);\n\n-- +migrate Down\nDROP TABLE "+table+";\n")
+	}
+
+	status := func(t *testing.T) []Migration {
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+		return migrations
+	}
+
+	t.Run("Goto Up", func(t *testing.T) {
+		if err := m.Goto("20240102000000"); err != nil {
+			t.Fatalf("迁移到指定版本失败: %v", err)
+		}
+		migrations := status(t)
+		if migrations[1].Status != StatusApplied || migrations[2].Status != StatusPending {
+			t.Errorf("应只应用前两个迁移: %v", migrations)
+		}
+	})
+
+	t.Run("Goto Down", func(t *testing.T) {
+		if err := m.Up(0); err != nil {
+			t.Fatalf("向上迁移失败: %v", err)
+		}
+		if err := m.Goto("20240101000000"); err != nil {
+			t.Fatalf("迁移到指定版本失败: %v", err)
+		}
+		migrations := status(t)
+		if countStatus(migrations, StatusApplied) != 1 || migrations[0].Status != StatusApplied {
+			t.Errorf("应只保留第一个迁移: %v", migrations)
+		}
+	})
+
+	t.Run("Goto Unknown", func(t *testing.T) {
+		if err := m.Goto("20991231000000"); err == nil {
+			t.Errorf("迁移到不存在的版本应返回错误")
+		}
+	})
+
+	t.Run("Redo", func(t *testing.T) {
+		if err := m.Up(0); err != nil {
+			t.Fatalf("向上迁移失败: %v", err)
+		}
+		if err := m.Redo(); err != nil {
+			t.Fatalf("重新执行迁移失败: %v", err)
+		}
+		migrations := status(t)
+		if countStatus(migrations, StatusApplied) != 3 {
+			t.Errorf("重新执行后所有迁移应为已应用: %v", migrations)
+		}
+		if migrations[0].BatchID != 1 || migrations[2].BatchID != 2 {
+			t.Errorf("重新执行的迁移应保持原批次号: %v", migrations)
+		}
+	})
+
+	t.Run("Baseline", func(t *testing.T) {
+		if err := m.Reset(); err != nil {
+			t.Fatalf("重置迁移失败: %v", err)
+		}
+		if err := m.Down(0); err != nil {
+			t.Fatalf("回滚迁移失败: %v", err)
+		}
+
+		// 模拟已有数据库中已存在的表
+		if _, err := m.db.Exec("CREATE TABLE a (id INTEGER PRIMARY KEY)"); err != nil {
+			t.Fatalf("创建表失败: %v", err)
+		}
+
+		if err := m.Baseline("20240101000000"); err != nil {
+			t.Fatalf("设置基线失败: %v", err)
+		}
+		if err := m.Up(0); err != nil {
+			t.Fatalf("设置基线后向上迁移失败: %v", err)
+		}
+		if countStatus(status(t), StatusApplied) != 3 {
+			t.Errorf("设置基线后所有迁移应为已应用")
+		}
+	})
+}
+
+// 测试重置、刷新和重建数据库
+func TestResetRefreshFresh(t *testing.T) {
+	m := newTestMigrator(t)
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+
+	if err := m.Up(0); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	// 没有Down部分的待执行迁移不影响重置
+	writeMigration(t, m, "20240102000000_seed.sql", `-- +migrate Up
+CREATE TABLE seed (id INTEGER PRIMARY KEY);
+`)
+
+	t.Run("Reset", func(t *testing.T) {
+		if err := m.Reset(); err != nil {
+			t.Fatalf("重置迁移失败: %v", err)
+		}
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+		if countStatus(migrations, StatusPending) != 2 {
+			t.Errorf("重置后所有迁移应为待执行: %v", migrations)
+		}
+	})
+
+	t.Run("Refresh Irreversible", func(t *testing.T) {
+		if err := m.Up(0); err != nil {
+			t.Fatalf("向上迁移失败: %v", err)
+		}
+		if err := m.Refresh(); err == nil {
+			t.Errorf("存在不可回滚的迁移时刷新应返回错误")
+		}
+	})
+
+	t.Run("Fresh", func(t *testing.T) {
+		if _, err := m.db.Exec("CREATE TABLE leftover (id INTEGER PRIMARY KEY)"); err != nil {
+			t.Fatalf("创建表失败: %v", err)
+		}
+
+		if err := m.Fresh(); err != nil {
+			t.Fatalf("重建数据库失败: %v", err)
+		}
+
+		exists, err := m.dialect.TableExists(context.Background(), m.db, "leftover")
+		if err != nil {
+			t.Fatalf("检查表失败: %v", err)
+		}
+		if exists {
+			t.Errorf("重建后不应保留迁移之外的表")
+		}
+
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+		if countStatus(migrations, StatusApplied) != 2 {
+			t.Errorf("重建后所有迁移应为已应用: %v", migrations)
+		}
+	})
+}
+
+// 测试乱序迁移和重复迁移ID的检测
+func TestOutOfOrderAndDuplicates(t *testing.T) {
+	m := newTestMigrator(t)
+	writeMigration(t, m, "20240102000000_create_posts.sql", `-- +migrate Up
+CREATE TABLE posts (id INTEGER PRIMARY KEY);
+`)
+	writeMigration(t, m, "README.md", "迁移说明")
+
+	if err := m.Up(0); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	// 模拟较晚合并的分支带来的较早迁移
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY);
+`)
+
+	t.Run("Refuse Out Of Order", func(t *testing.T) {
+		if err := m.Up(0); err == nil {
+			t.Fatalf("存在乱序迁移时应拒绝执行")
+		}
+	})
+
+	t.Run("Allow Out Of Order", func(t *testing.T) {
+		m.SetAllowOutOfOrder(true)
+		defer m.SetAllowOutOfOrder(false)
+
+		if err := m.Up(0); err != nil {
+			t.Fatalf("允许乱序时向上迁移失败: %v", err)
+		}
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+		if countStatus(migrations, StatusApplied) != 2 {
+			t.Errorf("所有迁移应为已应用: %v", migrations)
+		}
+	})
+
+	t.Run("Duplicate ID", func(t *testing.T) {
+		writeMigration(t, m, "20240101000000_create_accounts.sql", `-- +migrate Up
+CREATE TABLE accounts (id INTEGER PRIMARY KEY);
+`)
+		if _, err := m.Status(); err == nil {
+			t.Errorf("存在重复迁移ID时应返回错误")
+		}
+	})
+}
+
+// 测试结构快照和合并迁移
+func TestSchemaDumpAndSquash(t *testing.T) {
+	m := newTestMigrator(t)
+	m.schemaFile = filepath.Join(t.TempDir(), "db", "schema.sql")
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE users;
+`)
+	writeMigration(t, m, "20240102000000_create_posts.sql", `-- +migrate Up
+CREATE TABLE posts (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE posts;
+`)
+	writeMigration(t, m, "20240103000000_create_tags.sql", `-- +migrate Up
+CREATE TABLE tags (id INTEGER PRIMARY KEY);
+
+-- +migrate Down
+DROP TABLE tags;
+`)
+
+	// 另一个使用相同迁移文件的数据库
+	other := newTestMigrator(t)
+	other.migrationsDir = m.migrationsDir
+	if err := other.Up(2); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	if err := m.Up(2); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	t.Run("Schema Dump", func(t *testing.T) {
+		content, err := os.ReadFile(m.schemaFile)
+		if err != nil {
+			t.Fatalf("读取结构快照失败: %v", err)
+		}
+		dump := string(content)
+		if !strings.Contains(dump, "CREATE TABLE posts") || strings.Contains(dump, "schema_migrations") {
+			t.Errorf("结构快照内容不正确:\n%s", dump)
+		}
+	})
+
+	t.Run("Squash Failure Keeps Files", func(t *testing.T) {
+		// 合并迁移记录失败时，迁移文件和记录都应保持不变
+		trigger := fmt.Sprintf(`CREATE TRIGGER block_delete BEFORE DELETE ON %s BEGIN SELECT RAISE(ABORT, 'blocked'); END`, m.schemaTable)
+		if _, err := m.db.Exec(trigger); err != nil {
+			t.Fatalf("创建触发器失败: %v", err)
+		}
+		_, err := m.Squash("20240102000000")
+		if _, dropErr := m.db.Exec("DROP TRIGGER block_delete"); dropErr != nil {
+			t.Fatalf("删除触发器失败: %v", dropErr)
+		}
+		if err == nil {
+			t.Fatal("合并迁移记录失败时应返回错误")
+		}
+
+		entries, err := os.ReadDir(m.migrationsDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		want := "20240101000000_create_users.sql 20240102000000_create_posts.sql 20240103000000_create_tags.sql"
+		if strings.Join(names, " ") != want {
+			t.Errorf("迁移目录内容为 %v", names)
+		}
+
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+		if countStatus(migrations, StatusApplied) != 2 {
+			t.Errorf("迁移记录不应改变: %+v", migrations)
+		}
+	})
+
+	t.Run("Squash", func(t *testing.T) {
+		squashed, err := m.Squash("20240102000000")
+		if err != nil {
+			t.Fatalf("合并迁移失败: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(m.migrationsDir, "20240101000000_create_users.sql")); !os.IsNotExist(err) {
+			t.Errorf("被合并的迁移文件应被删除")
+		}
+
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+		if len(migrations) != 2 || migrations[0].ID != squashed.ID || migrations[0].Status != StatusApplied || migrations[0].Drifted {
+			t.Errorf("合并后应只剩基线迁移和待执行迁移: %+v", migrations)
+		}
+	})
+
+	t.Run("Down Squashed", func(t *testing.T) {
+		err := m.Down(0)
+		if err == nil || !strings.Contains(err.Error(), "migrate fresh") {
+			t.Errorf("回滚基线迁移应返回明确的错误: %v", err)
+		}
+	})
+
+	t.Run("Sync Other Database", func(t *testing.T) {
+		migrations, err := other.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+		if countStatus(migrations, StatusMissing) != 0 || countStatus(migrations, StatusApplied) != 1 || migrations[0].Drifted {
+			t.Errorf("其他数据库的迁移记录应被合并: %+v", migrations)
+		}
+		if err := other.Up(0); err != nil {
+			t.Fatalf("向上迁移失败: %v", err)
+		}
+	})
+
+	t.Run("Apply On Empty Database", func(t *testing.T) {
+		fresh := newTestMigrator(t)
+		fresh.migrationsDir = m.migrationsDir
+		if err := fresh.Up(0); err != nil {
+			t.Fatalf("在空数据库上应用合并后的迁移失败: %v", err)
+		}
+	})
+
+	t.Run("Resquash Failure Restores Baseline", func(t *testing.T) {
+		if err := m.Up(0); err != nil {
+			t.Fatalf("向上迁移失败: %v", err)
+		}
+		baselinePath := filepath.Join(m.migrationsDir, "20240102000000_"+squashedName+".sql")
+		baseline, err := os.ReadFile(baselinePath)
+		if err != nil {
+			t.Fatalf("读取基线迁移失败: %v", err)
+		}
+
+		// 再次合并时记录合并失败，原基线迁移应恢复
+		trigger := fmt.Sprintf(`CREATE TRIGGER block_delete BEFORE DELETE ON %s BEGIN SELECT RAISE(ABORT, 'blocked'); END`, m.schemaTable)
+		if _, err := m.db.Exec(trigger); err != nil {
+			t.Fatalf("创建触发器失败: %v", err)
+		}
+		_, err = m.Squash("20240103000000")
+		if _, dropErr := m.db.Exec("DROP TRIGGER block_delete"); dropErr != nil {
+			t.Fatalf("删除触发器失败: %v", dropErr)
+		}
+		if err == nil {
+			t.Fatal("合并迁移记录失败时应返回错误")
+		}
+
+		entries, err := os.ReadDir(m.migrationsDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		want := "20240102000000_" + squashedName + ".sql 20240103000000_create_tags.sql"
+		if strings.Join(names, " ") != want {
+			t.Errorf("迁移目录内容为 %v", names)
+		}
+		if restored, err := os.ReadFile(baselinePath); err != nil || string(restored) != string(baseline) {
+			t.Errorf("原基线迁移应保持不变: %v", err)
+		}
+	})
+}
+
+// 测试去除 pg_dump 输出中的会话设置和 psql 元命令
+func TestCleanPgDump(t *testing.T) {
+	dump := `--
+-- PostgreSQL database dump
+--
+
+\restrict 3qkQ0fHc9aXbE2yTcL1pWcXs
+
+-- Dumped from database version 17.6
+-- Dumped by pg_dump version 17.6
+
+SET statement_timeout = 0;
+SET lock_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET check_function_bodies = false;
+SET xmloption = content;
+SET client_min_messages = warning;
+SET row_security = off;
+
+--
+-- Name: touch_updated_at(); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.touch_updated_at() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    -- 更新修改时间
+    SET LOCAL timezone = 'UTC';
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$;
+
+
+SET default_tablespace = '';
+
+SET default_table_access_method = heap;
+
+--
+-- Name: users; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    email text NOT NULL
+);
+
+
+--
+-- PostgreSQL database dump complete
+--
+
+\unrestrict 3qkQ0fHc9aXbE2yTcL1pWcXs
+`
+
+	cleaned := cleanPgDump(dump)
+	for _, notWant := range []string{`\restrict`, `\unrestrict`, "set_config", "SET statement_timeout", "SET default_tablespace", "Dumped from"} {
+		if strings.Contains(cleaned, notWant) {
+			t.Errorf("不应包含 %s:\n%s", notWant, cleaned)
+		}
+	}
+	for _, want := range []string{"CREATE FUNCTION public.touch_updated_at()", "    -- 更新修改时间", "    SET LOCAL timezone = 'UTC';", "CREATE TABLE public.users ("} {
+		if !strings.Contains(cleaned, want) {
+			t.Errorf("缺少 %s:\n%s", want, cleaned)
+		}
+	}
+	if strings.Contains(cleaned, "\n\n\n") {
+		t.Errorf("不应包含连续空行:\n%s", cleaned)
+	}
+
+	// 合并生成的迁移中不再包含会话设置，执行后不影响迁移记录表的读写
+	content := squashedContent([]Migration{{ID: "20240101000000"}}, []string{cleaned})
+	parsed, err := ParseMigration(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("解析合并后的迁移失败: %v", err)
+	}
+	if len(parsed.UpStatements) != 1 || strings.Contains(parsed.UpStatements[0], "search_path") {
+		t.Errorf("合并后的迁移语句不正确: %q", parsed.UpStatements)
+	}
+}
+
+// 测试从连接字符串中取出密码
+func TestSplitPgPassword(t *testing.T) {
+	tests := []struct {
+		name         string
+		dsn          string
+		wantDSN      string
+		wantPassword string
+	}{
+		{"key value", "host=db port=5432 user=app password=s3cret dbname=app sslmode=disable", "host=db port=5432 user=app dbname=app sslmode=disable", "s3cret"},
+		{"empty password", "host=db user=app password= dbname=app", "host=db user=app dbname=app", ""},
+		{"url", "postgres://app:s3cret@db:5432/app?sslmode=disable", "postgres://app@db:5432/app?sslmode=disable", "s3cret"},
+		{"url without password", "postgres://app@db/app", "postgres://app@db/app", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dsn, password := splitPgPassword(tt.dsn)
+			if dsn != tt.wantDSN || password != tt.wantPassword {
+				t.Errorf("结果为 %q, %q，期望 %q, %q", dsn, password, tt.wantDSN, tt.wantPassword)
+			}
+		})
+	}
+}
+
+// 测试机器可读的迁移状态输出
+func TestFormatMigrationStatusAs(t *testing.T) {
+	appliedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	migrations := []Migration{
+		{ID: "20240101000000", Name: "create_users", Type: TypeSQL, Status: StatusApplied, AppliedAt: appliedAt, BatchID: 1, Checksum: "abc", Reversible: true},
+		{ID: "20240102000000", Name: "create_posts", Type: TypeGoFn, Status: StatusPending},
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		out, err := FormatMigrationStatusAs(migrations, OutputJSON)
+		if err != nil {
+			t.Fatalf("格式化失败: %v", err)
+		}
+		for _, want := range []string{`"id": "20240101000000"`, `"applied_at": "2024-01-01T12:00:00Z"`, `"applied_at": null`, `"checksum": "abc"`, `"reversible": true`} {
+			if !strings.Contains(out, want) {
+				t.Errorf("输出中缺少 %s:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		out, err := FormatMigrationStatusAs(migrations, OutputYAML)
+		if err != nil {
+			t.Fatalf("格式化失败: %v", err)
+		}
+		if !strings.Contains(out, "status: PENDING") || !strings.Contains(out, "batch: 1") {
+			t.Errorf("YAML输出不正确:\n%s", out)
+		}
+	})
+
+	t.Run("Unknown Format", func(t *testing.T) {
+		if _, err := FormatMigrationStatusAs(migrations, "xml"); err == nil {
+			t.Errorf("不支持的格式应返回错误")
+		}
+	})
+
+	if !HasPending(migrations) || HasPending(migrations[:1]) {
+		t.Errorf("待执行迁移检测不正确")
+	}
+}
@@ -0,0 +1,117 @@
+package migrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 迁移状态的输出格式
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// statusEntry 机器可读的迁移状态
+type statusEntry struct {
+	ID         string     `json:"id" yaml:"id"`
+	Name       string     `json:"name" yaml:"name"`
+	Type       string     `json:"type" yaml:"type"`
+	Status     string     `json:"status" yaml:"status"`
+	AppliedAt  *time.Time `json:"applied_at" yaml:"applied_at"`
+	Batch      int        `json:"batch" yaml:"batch"`
+	Checksum   string     `json:"checksum" yaml:"checksum"`
+	Reversible bool       `json:"reversible" yaml:"reversible"`
+	Drifted    bool       `json:"drifted" yaml:"drifted"`
+}
+
+// newStatusEntry 将迁移转换为输出记录
+func newStatusEntry(m Migration) statusEntry {
+	entry := statusEntry{
+		ID:         m.ID,
+		Name:       m.Name,
+		Type:       string(m.Type),
+		Status:     string(m.Status),
+		Batch:      m.BatchID,
+		Checksum:   m.Checksum,
+		Reversible: m.Reversible,
+		Drifted:    m.Drifted,
+	}
+	if m.Status != StatusPending && !m.AppliedAt.IsZero() {
+		appliedAt := m.AppliedAt
+		entry.AppliedAt = &appliedAt
+	}
+	return entry
+}
+
+// FormatMigrationStatusAs 按指定格式输出迁移状态
+func FormatMigrationStatusAs(migrations []Migration, format string) (string, error) {
+	if format == "" || format == OutputTable {
+		return FormatMigrationStatus(migrations), nil
+	}
+
+	entries := make([]statusEntry, 0, len(migrations))
+	for _, m := range migrations {
+		entries = append(entries, newStatusEntry(m))
+	}
+	return marshalOutput(entries, format)
+}
+
+// FormatTargetStatusAs 按指定格式输出多个数据库的迁移状态
+func FormatTargetStatusAs(targets []TargetStatus, format string) (string, error) {
+	if format == "" || format == OutputTable {
+		return FormatTargetStatus(targets), nil
+	}
+
+	type targetEntry struct {
+		Database   string        `json:"database" yaml:"database"`
+		Error      string        `json:"error,omitempty" yaml:"error,omitempty"`
+		Migrations []statusEntry `json:"migrations" yaml:"migrations"`
+	}
+
+	entries := make([]targetEntry, 0, len(targets))
+	for _, t := range targets {
+		entry := targetEntry{Database: t.Target, Migrations: []statusEntry{}}
+		if t.Err != nil {
+			entry.Error = t.Err.Error()
+		}
+		for _, m := range t.Migrations {
+			entry.Migrations = append(entry.Migrations, newStatusEntry(m))
+		}
+		entries = append(entries, entry)
+	}
+	return marshalOutput(entries, format)
+}
+
+// marshalOutput 将数据序列化为JSON或YAML
+func marshalOutput(v interface{}, format string) (string, error) {
+	switch format {
+	case OutputJSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("序列化为JSON失败: %w", err)
+		}
+		return string(data), nil
+	case OutputYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("序列化为YAML失败: %w", err)
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+// HasPending 检查是否存在待执行(含执行失败)的迁移
+func HasPending(migrations []Migration) bool {
+	for _, m := range migrations {
+		if m.Status == StatusPending || m.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package migrator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// directivePrefix 迁移指令前缀
+	directivePrefix = "-- +migrate "
+
+	directiveUp             = "Up"
+	directiveDown           = "Down"
+	directiveStatementBegin = "StatementBegin"
+	directiveStatementEnd   = "StatementEnd"
+	directiveNoTransaction  = "NoTransaction"
+	directiveSquashed       = "Squashed"
+	directiveEnvironment    = "Environment"
+)
+
+// ParsedMigration 表示解析后的SQL迁移文件
+type ParsedMigration struct {
+	UpStatements   []string // 向上迁移语句
+	DownStatements []string // 向下迁移语句
+	HasDown        bool     // 是否包含 -- +migrate Down 部分
+	NoTransaction  bool     // 是否声明了 -- +migrate NoTransaction，不在事务中执行
+	Squashed       bool     // 是否声明了 -- +migrate Squashed，由 migrate squash 合并生成
+	Environments   []string // -- +migrate Environment 声明的运行环境，为空表示所有环境
+}
+
+// ParseMigrationFile 解析SQL迁移文件
+func ParseMigrationFile(path string) (*ParsedMigration, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开迁移文件失败: %w", err)
+	}
+	defer f.Close()
+
+	parsed, err := ParseMigration(f)
+	if err != nil {
+		return nil, fmt.Errorf("解析迁移文件 %s 失败: %w", path, err)
+	}
+	return parsed, nil
+}
+
+// ParseMigration 解析SQL迁移内容
+//
+// 文件通过 -- +migrate Up / -- +migrate Down 划分向上和向下部分，
+// 语句以行尾的分号结束。函数、触发器等内部包含分号的语句需要放在
+// -- +migrate StatementBegin 和 -- +migrate StatementEnd 之间。
+// 无法在事务中执行的语句(如 CREATE INDEX CONCURRENTLY)需要在文件中
+// 声明 -- +migrate NoTransaction。
+func ParseMigration(r io.Reader) (*ParsedMigration, error) {
+	parsed := &ParsedMigration{}
+
+	var (
+		section     string // 当前所在部分: Up / Down / 空(文件头)
+		hasUp       bool
+		inStatement bool // 是否在StatementBegin块中
+		buf         strings.Builder
+		lineNo      int
+	)
+
+	// 将缓冲区中的语句写入当前部分
+	flush := func() {
+		stmt := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if stmt == "" {
+			return
+		}
+		if section == directiveUp {
+			parsed.UpStatements = append(parsed.UpStatements, stmt)
+		} else if section == directiveDown {
+			parsed.DownStatements = append(parsed.DownStatements, stmt)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, directivePrefix) {
+			directive := strings.TrimSpace(strings.TrimPrefix(trimmed, directivePrefix))
+
+			// Environment 指令带有参数: -- +migrate Environment development testing
+			if fields := strings.Fields(directive); len(fields) > 0 && fields[0] == directiveEnvironment {
+				if len(fields) == 1 {
+					return nil, fmt.Errorf("第%d行: Environment 指令缺少环境名称", lineNo)
+				}
+				parsed.Environments = append(parsed.Environments, fields[1:]...)
+				continue
+			}
+
+			switch directive {
+			case directiveUp, directiveDown:
+				if inStatement {
+					return nil, fmt.Errorf("第%d行: StatementBegin 块未结束", lineNo)
+				}
+				if (directive == directiveUp && hasUp) || (directive == directiveDown && parsed.HasDown) {
+					return nil, fmt.Errorf("第%d行: 重复的 %s 部分", lineNo, directive)
+				}
+				flush()
+				section = directive
+				if directive == directiveUp {
+					hasUp = true
+				} else {
+					parsed.HasDown = true
+				}
+			case directiveStatementBegin:
+				if section == "" {
+					return nil, fmt.Errorf("第%d行: StatementBegin 必须位于 Up 或 Down 部分中", lineNo)
+				}
+				if inStatement {
+					return nil, fmt.Errorf("第%d行: 嵌套的 StatementBegin", lineNo)
+				}
+				flush()
+				inStatement = true
+			case directiveStatementEnd:
+				if !inStatement {
+					return nil, fmt.Errorf("第%d行: StatementEnd 缺少对应的 StatementBegin", lineNo)
+				}
+				flush()
+				inStatement = false
+			case directiveNoTransaction:
+				parsed.NoTransaction = true
+			case directiveSquashed:
+				parsed.Squashed = true
+			default:
+				return nil, fmt.Errorf("第%d行: 未知的迁移指令 %q", lineNo, directive)
+			}
+			continue
+		}
+
+		// 文件头部的注释和说明直接忽略
+		if section == "" {
+			continue
+		}
+
+		if inStatement {
+			buf.WriteString(line)
+			buf.WriteString("\n")
+			continue
+		}
+
+		// 语句之间的空行和整行注释不计入语句
+		if buf.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取迁移内容失败: %w", err)
+	}
+
+	if inStatement {
+		return nil, fmt.Errorf("StatementBegin 块未结束")
+	}
+	if !hasUp {
+		return nil, fmt.Errorf("缺少 %s%s 部分", directivePrefix, directiveUp)
+	}
+
+	flush()
+	return parsed, nil
+}
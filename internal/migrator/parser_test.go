@@ -0,0 +1,134 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+)
+
+// 测试迁移文件解析
+func TestParseMigration(t *testing.T) {
+	t.Run("Up And Down Sections", func(t *testing.T) {
+		content := `-- 迁移: create_users
+-- 说明: 头部注释会被忽略
+
+-- +migrate Up
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL
+);
+-- 语句之间的注释
+CREATE INDEX idx_users_name ON users (name);
+
+-- +migrate Down
+DROP TABLE users;
+`
+		parsed, err := ParseMigration(strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+
+		if len(parsed.UpStatements) != 2 {
+			t.Fatalf("应有2条Up语句，实际为%d: %q", len(parsed.UpStatements), parsed.UpStatements)
+		}
+		if !strings.HasPrefix(parsed.UpStatements[0], "CREATE TABLE users") {
+			t.Errorf("第一条Up语句不正确: %q", parsed.UpStatements[0])
+		}
+		if parsed.UpStatements[1] != "CREATE INDEX idx_users_name ON users (name);" {
+			t.Errorf("第二条Up语句不正确: %q", parsed.UpStatements[1])
+		}
+		if len(parsed.DownStatements) != 1 || parsed.DownStatements[0] != "DROP TABLE users;" {
+			t.Errorf("Down语句不正确: %q", parsed.DownStatements)
+		}
+		if !parsed.HasDown {
+			t.Errorf("应识别到Down部分")
+		}
+	})
+
+	t.Run("Statement Block", func(t *testing.T) {
+		content := `-- +migrate Up
+-- +migrate StatementBegin
+CREATE FUNCTION touch_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+CREATE TABLE t (id INT);
+
+-- +migrate Down
+DROP FUNCTION touch_updated_at();
+`
+		parsed, err := ParseMigration(strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+
+		if len(parsed.UpStatements) != 2 {
+			t.Fatalf("应有2条Up语句，实际为%d: %q", len(parsed.UpStatements), parsed.UpStatements)
+		}
+		if !strings.Contains(parsed.UpStatements[0], "RETURN NEW;") || !strings.HasSuffix(parsed.UpStatements[0], "LANGUAGE plpgsql;") {
+			t.Errorf("StatementBegin块应作为一条语句: %q", parsed.UpStatements[0])
+		}
+	})
+
+	t.Run("Missing Down Section", func(t *testing.T) {
+		parsed, err := ParseMigration(strings.NewReader("-- +migrate Up\nCREATE TABLE t (id INT);\n"))
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+		if parsed.HasDown {
+			t.Errorf("没有Down部分时HasDown应为false")
+		}
+	})
+
+	t.Run("Trailing Statement Without Semicolon", func(t *testing.T) {
+		parsed, err := ParseMigration(strings.NewReader("-- +migrate Up\nCREATE TABLE t (id INT)\n-- +migrate Down\nDROP TABLE t\n"))
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+		if len(parsed.UpStatements) != 1 || len(parsed.DownStatements) != 1 {
+			t.Errorf("末尾无分号的语句也应被识别: %q %q", parsed.UpStatements, parsed.DownStatements)
+		}
+	})
+
+	t.Run("No Transaction", func(t *testing.T) {
+		parsed, err := ParseMigration(strings.NewReader("-- +migrate NoTransaction\n-- +migrate Up\nCREATE INDEX CONCURRENTLY idx ON t (id);\n"))
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+		if !parsed.NoTransaction {
+			t.Errorf("应识别到 NoTransaction 指令")
+		}
+	})
+
+	t.Run("Environment", func(t *testing.T) {
+		parsed, err := ParseMigration(strings.NewReader("-- +migrate Environment development testing\n-- +migrate Up\nINSERT INTO t VALUES (1);\n"))
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+		if strings.Join(parsed.Environments, ",") != "development,testing" {
+			t.Errorf("运行环境不正确: %q", parsed.Environments)
+		}
+	})
+
+	errorCases := []struct {
+		name    string
+		content string
+	}{
+		{"Missing Up", "CREATE TABLE t (id INT);\n"},
+		{"Unclosed Block", "-- +migrate Up\n-- +migrate StatementBegin\nSELECT 1;\n"},
+		{"End Without Begin", "-- +migrate Up\n-- +migrate StatementEnd\n"},
+		{"Duplicate Up", "-- +migrate Up\nSELECT 1;\n-- +migrate Up\n"},
+		{"Unknown Directive", "-- +migrate Up\n-- +migrate Sideways\n"},
+		{"Empty Environment", "-- +migrate Environment\n-- +migrate Up\n"},
+	}
+
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseMigration(strings.NewReader(tc.content)); err == nil {
+				t.Errorf("应返回解析错误")
+			}
+		})
+	}
+}
@@ -0,0 +1,426 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// planStep 迁移计划中的一步
+type planStep struct {
+	Migration Migration // 要执行的迁移
+	Up        bool      // true为应用，false为回滚
+	Batch     int       // 应用时写入的批次号
+	Baseline  bool      // 只写入迁移记录而不执行迁移
+}
+
+// planner 根据当前迁移状态生成迁移计划
+type planner func(migrations []Migration) ([]planStep, error)
+
+// planUp 生成应用待执行迁移的计划，steps为0表示全部
+func planUp(steps int) planner {
+	return func(migrations []Migration) ([]planStep, error) {
+		// 同一次执行的迁移属于同一批次
+		batch := nextBatch(migrations)
+
+		var plan []planStep
+		for _, migration := range migrations {
+			if steps > 0 && len(plan) >= steps {
+				break
+			}
+			// 执行失败的迁移会被重新执行
+			if migration.Status == StatusPending || migration.Status == StatusFailed {
+				plan = append(plan, planStep{Migration: migration, Up: true, Batch: batch})
+			}
+		}
+		return plan, nil
+	}
+}
+
+// planDown 生成回滚已应用迁移的计划，steps为0表示全部
+func planDown(steps int) planner {
+	return func(migrations []Migration) ([]planStep, error) {
+		var applied []Migration
+		for _, migration := range migrations {
+			if migration.Status == StatusApplied {
+				applied = append(applied, migration)
+			}
+		}
+
+		// 按批次倒序、同批次内按ID倒序回滚
+		sort.Slice(applied, func(i, j int) bool {
+			if applied[i].BatchID != applied[j].BatchID {
+				return applied[i].BatchID > applied[j].BatchID
+			}
+			return applied[i].ID > applied[j].ID
+		})
+
+		if steps > 0 && steps < len(applied) {
+			applied = applied[:steps]
+		}
+
+		// 回滚前检查所有待回滚迁移是否可回滚
+		plan := make([]planStep, 0, len(applied))
+		for _, migration := range applied {
+			if err := checkReversible(migration); err != nil {
+				return nil, err
+			}
+			plan = append(plan, planStep{Migration: migration, Up: false, Batch: migration.BatchID})
+		}
+		return plan, nil
+	}
+}
+
+// planGoto 生成迁移到指定版本的计划
+//
+// 先按ID倒序回滚所有ID大于目标版本的已应用迁移，
+// 再应用所有ID不大于目标版本的待执行迁移。
+func planGoto(version string) planner {
+	return func(migrations []Migration) ([]planStep, error) {
+		if err := checkVersion(migrations, version); err != nil {
+			return nil, err
+		}
+
+		batch := nextBatch(migrations)
+
+		var down, up []planStep
+		for i := len(migrations) - 1; i >= 0; i-- {
+			migration := migrations[i]
+			if migration.ID <= version || migration.Status != StatusApplied {
+				continue
+			}
+			if err := checkReversible(migration); err != nil {
+				return nil, err
+			}
+			down = append(down, planStep{Migration: migration, Up: false, Batch: migration.BatchID})
+		}
+		for _, migration := range migrations {
+			if migration.ID > version {
+				break
+			}
+			if migration.Status == StatusPending || migration.Status == StatusFailed {
+				up = append(up, planStep{Migration: migration, Up: true, Batch: batch})
+			}
+		}
+
+		return append(down, up...), nil
+	}
+}
+
+// planRedo 生成回滚并重新应用最后一个批次的计划
+func planRedo() planner {
+	return func(migrations []Migration) ([]planStep, error) {
+		last := 0
+		for _, migration := range migrations {
+			if migration.Status == StatusApplied && migration.BatchID > last {
+				last = migration.BatchID
+			}
+		}
+		if last == 0 {
+			return nil, nil
+		}
+
+		var batch []Migration
+		for _, migration := range migrations {
+			if migration.Status == StatusApplied && migration.BatchID == last {
+				batch = append(batch, migration)
+			}
+		}
+
+		plan := make([]planStep, 0, len(batch)*2)
+		for i := len(batch) - 1; i >= 0; i-- {
+			migration := batch[i]
+			if err := checkReversible(migration); err != nil {
+				return nil, err
+			}
+			plan = append(plan, planStep{Migration: migration, Up: false, Batch: last})
+		}
+		// 重新应用的迁移保持原批次号
+		for _, migration := range batch {
+			plan = append(plan, planStep{Migration: migration, Up: true, Batch: last})
+		}
+		return plan, nil
+	}
+}
+
+// planBaseline 生成将不大于指定版本的待执行迁移标记为已应用的计划
+func planBaseline(version string) planner {
+	return func(migrations []Migration) ([]planStep, error) {
+		if err := checkVersion(migrations, version); err != nil {
+			return nil, err
+		}
+
+		batch := nextBatch(migrations)
+
+		var plan []planStep
+		for _, migration := range migrations {
+			if migration.ID > version {
+				break
+			}
+			if migration.Status == StatusPending || migration.Status == StatusFailed {
+				plan = append(plan, planStep{Migration: migration, Up: true, Batch: batch, Baseline: true})
+			}
+		}
+		return plan, nil
+	}
+}
+
+// checkVersion 检查迁移版本是否存在
+func checkVersion(migrations []Migration, version string) error {
+	for _, migration := range migrations {
+		if migration.ID == version && migration.Status != StatusMissing {
+			return nil
+		}
+	}
+	return fmt.Errorf("未找到迁移 %s", version)
+}
+
+// applyPlan 返回执行计划后的迁移状态，不修改传入的切片
+func applyPlan(migrations []Migration, plan []planStep) []Migration {
+	result := make([]Migration, len(migrations))
+	copy(result, migrations)
+
+	index := make(map[string]int, len(result))
+	for i, migration := range result {
+		index[migration.ID] = i
+	}
+
+	for _, step := range plan {
+		i, ok := index[step.Migration.ID]
+		if !ok {
+			continue
+		}
+		if step.Up {
+			result[i].Status = StatusApplied
+			result[i].BatchID = step.Batch
+			result[i].Drifted = false
+		} else {
+			result[i].Status = StatusPending
+			result[i].BatchID = 0
+		}
+	}
+	return result
+}
+
+// nextBatch 计算下一个批次号
+func nextBatch(migrations []Migration) int {
+	batch := 0
+	for _, migration := range migrations {
+		if migration.Status != StatusPending && migration.BatchID > batch {
+			batch = migration.BatchID
+		}
+	}
+	return batch + 1
+}
+
+// SetDryRun 启用dry-run模式，只将要执行的SQL写入w而不修改数据库
+func (m *StandardMigrator) SetDryRun(w io.Writer) {
+	m.dryRun = w
+}
+
+// execute 在持有迁移锁时依次生成并执行迁移计划，dry-run模式下只输出计划
+func (m *StandardMigrator) execute(ctx context.Context, planners ...planner) error {
+	return m.withLock(ctx, func() error {
+		return m.executeLocked(ctx, planners...)
+	})
+}
+
+// executeLocked 生成并执行迁移计划，调用方需持有迁移锁
+func (m *StandardMigrator) executeLocked(ctx context.Context, planners ...planner) error {
+	migrations, err := m.loadMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 后一个计划基于前一个计划执行后的状态生成
+	var plan []planStep
+	for _, p := range planners {
+		steps, err := p(migrations)
+		if err != nil {
+			return err
+		}
+		if err := m.checkOutOfOrder(migrations, steps); err != nil {
+			return err
+		}
+		migrations = applyPlan(migrations, steps)
+		plan = append(plan, steps...)
+	}
+
+	if len(plan) == 0 {
+		logger.Info("没有需要执行的迁移")
+		return nil
+	}
+
+	if m.dryRun != nil {
+		return m.writePlan(plan)
+	}
+	if err := m.runPlan(ctx, plan); err != nil {
+		return err
+	}
+
+	// 结构快照写入失败不影响迁移结果
+	if err := m.writeSchemaFile(ctx, migrations); err != nil {
+		logger.Warn("%v", err)
+	}
+	return nil
+}
+
+// checkOutOfOrder 检查计划是否会在较新的已应用迁移之后应用较旧的迁移
+//
+// 多人协作时，较晚合并的分支可能带有ID早于最新已应用迁移的迁移文件。
+// 默认拒绝执行，设置 SetAllowOutOfOrder(true) 后只输出警告。
+func (m *StandardMigrator) checkOutOfOrder(migrations []Migration, plan []planStep) error {
+	inPlan := make(map[string]bool, len(plan))
+	for _, step := range plan {
+		inPlan[step.Migration.ID] = true
+	}
+
+	// 不受本次计划影响的已应用迁移中最大的ID
+	latest := ""
+	for _, migration := range migrations {
+		if migration.Status == StatusApplied && !inPlan[migration.ID] && migration.ID > latest {
+			latest = migration.ID
+		}
+	}
+
+	var outOfOrder []string
+	for _, step := range plan {
+		if step.Up && !step.Baseline && step.Migration.ID < latest {
+			outOfOrder = append(outOfOrder, fmt.Sprintf("%s (%s)", step.Migration.ID, step.Migration.Name))
+		}
+	}
+	if len(outOfOrder) == 0 {
+		return nil
+	}
+
+	if m.outOfOrder {
+		logger.Warn("以下迁移早于已应用的迁移 %s，将乱序应用: %s", latest, strings.Join(outOfOrder, ", "))
+		return nil
+	}
+	return fmt.Errorf("以下待执行迁移早于已应用的迁移 %s: %s；确认无冲突后可使用 --allow-out-of-order 应用",
+		latest, strings.Join(outOfOrder, ", "))
+}
+
+// runPlan 执行迁移计划
+func (m *StandardMigrator) runPlan(ctx context.Context, plan []planStep) error {
+	applied, rolledBack, baselined := 0, 0, 0
+	for _, step := range plan {
+		migration := step.Migration
+		if step.Baseline {
+			logger.Info("标记迁移为已应用: %s (%s)", migration.Name, migration.ID)
+			if err := m.saveRecord(ctx, m.db, migration, step.Batch, StatusApplied); err != nil {
+				return fmt.Errorf("标记迁移 %s 失败: %w", migration.ID, err)
+			}
+			baselined++
+		} else if step.Up {
+			logger.Info("应用迁移: %s (%s)", migration.Name, migration.ID)
+			if err := m.applyMigration(ctx, migration, step.Batch); err != nil {
+				return fmt.Errorf("应用迁移 %s 失败: %w", migration.ID, err)
+			}
+			applied++
+		} else {
+			logger.Info("回滚迁移: %s (%s)", migration.Name, migration.ID)
+			if err := m.rollbackMigration(ctx, migration); err != nil {
+				return fmt.Errorf("回滚迁移 %s 失败: %w", migration.ID, err)
+			}
+			rolledBack++
+		}
+	}
+
+	if rolledBack > 0 {
+		logger.Info("成功回滚 %d 个迁移", rolledBack)
+	}
+	if applied > 0 {
+		logger.Info("成功应用 %d 个迁移", applied)
+	}
+	if baselined > 0 {
+		logger.Info("成功标记 %d 个迁移为已应用", baselined)
+	}
+	return nil
+}
+
+// writePlan 以SQL脚本形式输出迁移计划
+func (m *StandardMigrator) writePlan(plan []planStep) error {
+	var b strings.Builder
+	b.WriteString("-- ParkerCli 迁移计划 (dry-run)\n")
+	b.WriteString(fmt.Sprintf("-- 生成时间: %s\n", time.Now().Format("2006-01-02 15:04:05")))
+	b.WriteString(fmt.Sprintf("-- 数据库驱动: %s\n", m.dbDriver))
+
+	for i, step := range plan {
+		migration := step.Migration
+		direction := "UP"
+		if step.Baseline {
+			direction = "BASELINE"
+		} else if !step.Up {
+			direction = "DOWN"
+		}
+
+		b.WriteString(fmt.Sprintf("\n-- [%d/%d] %s %s %s (批次: %d)\n",
+			i+1, len(plan), direction, migration.ID, migration.Name, step.Batch))
+
+		if step.Baseline {
+			b.WriteString(fmt.Sprintf("-- 记录迁移(不执行): INSERT INTO %s (version = '%s', batch = %d)\n",
+				m.schemaTable, migration.ID, step.Batch))
+			continue
+		}
+
+		if migration.Type == TypeGoFn {
+			fn := "Up_"
+			if !step.Up {
+				fn = "Down_"
+			}
+			b.WriteString(fmt.Sprintf("-- Go函数迁移 %s%s，无法预览SQL\n", fn, migration.ID))
+			continue
+		}
+
+		statements, useTx, err := m.migrationStatements(migration, step.Up)
+		if err != nil {
+			return err
+		}
+
+		if useTx {
+			b.WriteString("BEGIN;\n")
+		} else {
+			b.WriteString("-- 不使用事务 (NoTransaction)\n")
+		}
+		for _, stmt := range statements {
+			b.WriteString(stmt)
+			if !strings.HasSuffix(stmt, ";") {
+				b.WriteString(";")
+			}
+			b.WriteString("\n")
+		}
+		if step.Up {
+			b.WriteString(fmt.Sprintf("-- 记录迁移: INSERT INTO %s (version = '%s', batch = %d)\n",
+				m.schemaTable, migration.ID, step.Batch))
+		} else {
+			b.WriteString(fmt.Sprintf("-- 删除迁移记录: DELETE FROM %s WHERE version = '%s'\n",
+				m.schemaTable, migration.ID))
+		}
+		if useTx {
+			b.WriteString("COMMIT;\n")
+		}
+	}
+
+	if _, err := io.WriteString(m.dryRun, b.String()); err != nil {
+		return fmt.Errorf("输出迁移计划失败: %w", err)
+	}
+	return nil
+}
+
+// checkReversible 检查迁移是否可以回滚
+func checkReversible(migration Migration) error {
+	if migration.Squashed {
+		return fmt.Errorf("迁移 %s (%s) 是由 migrate squash 生成的基线迁移，不能回滚，需要清空数据库时请使用 migrate fresh",
+			migration.Name, migration.ID)
+	}
+	if !migration.Reversible {
+		return fmt.Errorf("迁移 %s (%s) 没有Down部分，无法回滚", migration.Name, migration.ID)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// GoMigrationFunc Go函数迁移签名，与 migrate create --type go 生成的 Up_/Down_ 函数一致
+type GoMigrationFunc func(ctx context.Context, db *sql.DB) error
+
+// goMigration 已注册的Go函数迁移
+type goMigration struct {
+	Up   GoMigrationFunc
+	Down GoMigrationFunc
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]goMigration)
+)
+
+// Register 注册Go函数迁移
+//
+// 在同一进程中注册的迁移会被直接调用；未注册的Go迁移由ParkerCli
+// 生成并编译一个临时运行器，在项目的迁移包中执行对应函数。
+func Register(id string, up, down GoMigrationFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if up == nil {
+		panic(fmt.Sprintf("migrator: 迁移 %s 的Up函数不能为空", id))
+	}
+	if _, exists := registry[id]; exists {
+		panic(fmt.Sprintf("migrator: 迁移 %s 重复注册", id))
+	}
+
+	registry[id] = goMigration{Up: up, Down: down}
+}
+
+// lookupGoMigration 查找已注册的Go函数迁移
+func lookupGoMigration(id string) (goMigration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	fn, ok := registry[id]
+	return fn, ok
+}
@@ -0,0 +1,430 @@
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/parker/ParkerCli/internal/utils"
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// squashedName 合并生成的基线迁移名称
+const squashedName = "squashed"
+
+// mysqlAutoIncrement 匹配 SHOW CREATE TABLE 输出中随数据变化的自增值
+var mysqlAutoIncrement = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+// internalTables 返回迁移器自身使用、不属于应用结构的表
+func (m *StandardMigrator) internalTables() []string {
+	return []string{m.schemaTable, sqliteLockTable(m.schemaTable), m.seedTable}
+}
+
+// writeSchemaFile 将当前数据库结构写入结构快照文件，便于在代码评审中查看结构变化
+func (m *StandardMigrator) writeSchemaFile(ctx context.Context, migrations []Migration) error {
+	if m.schemaFile == "" {
+		return nil
+	}
+
+	statements, err := m.dialect.DumpSchema(ctx, m.db, m.dbDSN, m.internalTables())
+	if err != nil {
+		return fmt.Errorf("导出数据库结构失败: %w", err)
+	}
+
+	// 不写入生成时间，避免结构未变化时文件也产生差异
+	version := ""
+	for _, migration := range migrations {
+		if migration.Status == StatusApplied && migration.ID > version {
+			version = migration.ID
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("-- 数据库结构快照，由 ParkerCli 在迁移后自动生成，请勿手动修改\n")
+	b.WriteString(fmt.Sprintf("-- 数据库驱动: %s\n", m.dbDriver))
+	b.WriteString(fmt.Sprintf("-- 迁移版本: %s\n", version))
+	for _, stmt := range statements {
+		b.WriteString("\n")
+		b.WriteString(stmt)
+		if !strings.HasSuffix(stmt, ";") {
+			b.WriteString(";")
+		}
+		b.WriteString("\n")
+	}
+
+	if err := utils.EnsureDir(filepath.Dir(m.schemaFile)); err != nil {
+		return fmt.Errorf("创建结构快照目录失败: %w", err)
+	}
+	if err := os.WriteFile(m.schemaFile, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("写入结构快照失败: %w", err)
+	}
+
+	logger.Debug("数据库结构快照已写入: %s", m.schemaFile)
+	return nil
+}
+
+// SetSchemaFile 设置迁移后写入数据库结构快照的文件，为空时不写入
+func (m *StandardMigrator) SetSchemaFile(path string) {
+	m.schemaFile = path
+}
+
+// Squash 将指定ID及之前的所有迁移合并为一个基于当前数据库结构的基线迁移
+//
+// 当前数据库必须恰好应用了指定ID及之前的全部迁移。合并后的迁移沿用指定的ID，
+// 被合并的迁移文件会被删除，迁移记录表中对应的记录合并为一条。其他数据库在下次
+// 执行迁移命令时会自动完成同样的记录合并。
+func (m *StandardMigrator) Squash(until string) (*Migration, error) {
+	logger.Info("合并 %s 及之前的迁移", until)
+
+	if err := m.writableSource(); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	var squashed *Migration
+	err := m.withLock(ctx, func() error {
+		migrations, err := m.loadMigrations(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(migrations, until); err != nil {
+			return err
+		}
+
+		var covered []Migration
+		for _, migration := range migrations {
+			if migration.ID <= until {
+				if migration.Status != StatusApplied && migration.Status != StatusMissing {
+					return fmt.Errorf("迁移 %s (%s) 尚未应用，请先执行 migrate goto %s", migration.ID, migration.Name, until)
+				}
+				covered = append(covered, migration)
+			} else if migration.Status == StatusApplied || migration.Status == StatusMissing {
+				return fmt.Errorf("迁移 %s (%s) 晚于 %s 但已应用，请先执行 migrate goto %s", migration.ID, migration.Name, until, until)
+			}
+		}
+
+		statements, err := m.dialect.DumpSchema(ctx, m.db, m.dbDSN, m.internalTables())
+		if err != nil {
+			return fmt.Errorf("导出数据库结构失败: %w", err)
+		}
+
+		content := squashedContent(covered, statements)
+		filePath := filepath.Join(m.migrationsDir, fmt.Sprintf("%s_%s.sql", until, squashedName))
+
+		// 先写入临时文件再移动到最终位置，迁移记录合并提交后才删除被合并的文件，
+		// 任一步失败时迁移文件和迁移记录都恢复为合并前的状态。
+		// 临时文件和备份文件以 . 开头，加载迁移时会被忽略
+		tmpPath := filepath.Join(m.migrationsDir, "."+filepath.Base(filePath)+".tmp")
+		if err := os.WriteFile(tmpPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("写入合并后的迁移文件失败: %w", err)
+		}
+
+		checksum, err := fileChecksum(tmpPath)
+		if err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+		squashed = &Migration{
+			ID:        until,
+			Name:      squashedName,
+			Type:      TypeSQL,
+			Status:    StatusApplied,
+			FilePath:  filePath,
+			Checksum:  checksum,
+			Squashed:  true,
+			BatchID:   covered[len(covered)-1].BatchID,
+			AppliedAt: covered[len(covered)-1].AppliedAt,
+		}
+
+		// 再次合并时，被合并的基线迁移与新文件同名，先备份以便失败时恢复
+		backupPath := ""
+		if utils.FileExists(filePath) {
+			backupPath = filepath.Join(m.migrationsDir, "."+filepath.Base(filePath)+".bak")
+			if err := os.Rename(filePath, backupPath); err != nil {
+				os.Remove(tmpPath)
+				return fmt.Errorf("备份基线迁移文件失败: %w", err)
+			}
+		}
+		restore := func() {
+			if backupPath != "" {
+				if err := os.Rename(backupPath, filePath); err != nil {
+					logger.Error("恢复基线迁移文件失败，请将 %s 重命名为 %s: %v", backupPath, filePath, err)
+				}
+			}
+		}
+
+		if err := os.Rename(tmpPath, filePath); err != nil {
+			os.Remove(tmpPath)
+			restore()
+			return fmt.Errorf("写入合并后的迁移文件失败: %w", err)
+		}
+
+		err = m.inTransaction(ctx, func(exec execer) error {
+			return m.mergeSquashedRecords(ctx, exec, *squashed, covered)
+		})
+		if err != nil {
+			os.Remove(filePath)
+			restore()
+			return err
+		}
+		if backupPath != "" {
+			os.Remove(backupPath)
+		}
+
+		// 迁移记录已合并，删除失败的文件需要手动删除
+		for _, migration := range covered {
+			if migration.FilePath == "" || migration.FilePath == filePath {
+				continue
+			}
+			if err := os.Remove(migration.FilePath); err != nil {
+				return fmt.Errorf("迁移记录已合并，但删除被合并的迁移文件失败，请手动删除: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("合并迁移失败: %w", err)
+	}
+
+	logger.Info("已生成合并后的迁移: %s", squashed.FilePath)
+	return squashed, nil
+}
+
+// squashedContent 生成合并后的基线迁移文件内容
+func squashedContent(covered []Migration, statements []string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("-- 迁移: %s\n", squashedName))
+	b.WriteString(fmt.Sprintf("-- 说明: 由 migrate squash 合并 %d 个迁移 (%s - %s) 生成\n",
+		len(covered), covered[0].ID, covered[len(covered)-1].ID))
+	b.WriteString(directivePrefix + directiveSquashed + "\n\n")
+	b.WriteString(directivePrefix + directiveUp + "\n")
+
+	writeStatements(&b, statements)
+
+	// 基线迁移没有Down部分，不可回滚，需要清空数据库时使用 migrate fresh
+	return b.String()
+}
+
+// writeStatements 将SQL语句写入迁移文件内容
+func writeStatements(b *strings.Builder, statements []string) {
+	for _, stmt := range statements {
+		stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+		// 内部包含分号的语句(如触发器、函数)需要整体执行
+		if strings.Contains(stmt, ";") {
+			b.WriteString(directivePrefix + directiveStatementBegin + "\n")
+			b.WriteString(stmt + ";\n")
+			b.WriteString(directivePrefix + directiveStatementEnd + "\n\n")
+		} else {
+			b.WriteString(stmt + ";\n\n")
+		}
+	}
+}
+
+// mergeSquashedRecords 删除被合并迁移的记录并写入基线迁移的记录
+func (m *StandardMigrator) mergeSquashedRecords(ctx context.Context, exec execer, squashed Migration, covered []Migration) error {
+	for _, migration := range covered {
+		if err := m.deleteRecord(ctx, exec, migration.ID); err != nil {
+			return err
+		}
+	}
+	return m.insertRecord(ctx, exec, squashed, squashed.BatchID, StatusApplied)
+}
+
+// syncSquashed 将其他数据库中被合并迁移的记录合并为基线迁移的记录
+func (m *StandardMigrator) syncSquashed(ctx context.Context, migrations []Migration, applied map[string]appliedRecord) error {
+	var squashed *Migration
+	files := make(map[string]bool, len(migrations))
+	for i := range migrations {
+		files[migrations[i].ID] = true
+		if migrations[i].Squashed {
+			squashed = &migrations[i]
+		}
+	}
+	if squashed == nil {
+		return nil
+	}
+
+	// 早于基线迁移且文件已不存在的记录属于被合并的迁移
+	var covered []Migration
+	for version, record := range applied {
+		if version < squashed.ID && !files[version] {
+			covered = append(covered, Migration{ID: version, BatchID: record.Batch})
+		}
+	}
+	record, ok := applied[squashed.ID]
+	if len(covered) == 0 && (!ok || record.Name == squashed.Name) {
+		return nil
+	}
+
+	if !ok || record.Status != StatusApplied {
+		return fmt.Errorf("数据库只应用了部分被合并到 %s 的迁移，请先使用合并前的迁移文件升级到 %s", squashed.ID, squashed.ID)
+	}
+
+	merged := *squashed
+	merged.BatchID = record.Batch
+	err := m.inTransaction(ctx, func(exec execer) error {
+		if err := m.deleteRecord(ctx, exec, squashed.ID); err != nil {
+			return err
+		}
+		return m.mergeSquashedRecords(ctx, exec, merged, covered)
+	})
+	if err != nil {
+		return fmt.Errorf("合并迁移记录失败: %w", err)
+	}
+
+	logger.Info("已将 %d 条被合并迁移的记录合并到 %s", len(covered), squashed.ID)
+	for _, migration := range covered {
+		delete(applied, migration.ID)
+	}
+	applied[squashed.ID] = appliedRecord{
+		Version:   squashed.ID,
+		Batch:     record.Batch,
+		AppliedAt: record.AppliedAt,
+		Status:    StatusApplied,
+		Name:      squashed.Name,
+		Checksum:  squashed.Checksum,
+	}
+	return nil
+}
+
+// DumpSchema 使用 pg_dump 导出数据库结构
+func (postgresDialect) DumpSchema(ctx context.Context, db *sql.DB, dsn string, exclude []string) ([]string, error) {
+	args := []string{"--schema-only", "--no-owner", "--no-privileges"}
+	for _, table := range exclude {
+		args = append(args, "--exclude-table="+table)
+	}
+	// 密码通过环境变量传递，避免出现在进程列表中
+	dsn, password := splitPgPassword(dsn)
+	args = append(args, "--dbname="+dsn)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "pg_dump", args...)
+	cmd.Stderr = &stderr
+	if password != "" {
+		cmd.Env = append(os.Environ(), "PGPASSWORD="+password)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("执行 pg_dump 失败: %w\n%s", err, stderr.String())
+	}
+
+	// pg_dump 的输出包含函数体等复杂语句，作为整体执行
+	return []string{cleanPgDump(string(output))}, nil
+}
+
+// pgDumpSessionLine 匹配 pg_dump 输出开头设置会话参数的语句
+var pgDumpSessionLine = regexp.MustCompile(`^(SET\s+\w+\s*=.*|SELECT pg_catalog\.set_config\(.*\));\s*$`)
+
+// cleanPgDump 去除 pg_dump 输出中只适用于恢复会话的内容
+//
+// pg_dump 会将 search_path 设为空并依赖对象名带上模式前缀，直接执行会导致后续
+// 不带模式前缀的语句(如迁移记录表的读写)失败；较新版本还会输出 \restrict 等
+// 只有 psql 能识别的元命令。这里去掉这些行以及注释，只保留结构定义语句。
+func cleanPgDump(dump string) string {
+	var lines []string
+	for _, line := range strings.Split(dump, "\n") {
+		// 只处理顶格的行，函数体等语句内部缩进的内容保持不变
+		if strings.HasPrefix(line, "\\") || strings.HasPrefix(line, "--") ||
+			pgDumpSessionLine.MatchString(strings.TrimRight(line, " \t\r")) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	// 合并连续空行
+	cleaned := strings.Join(lines, "\n")
+	for strings.Contains(cleaned, "\n\n\n") {
+		cleaned = strings.ReplaceAll(cleaned, "\n\n\n", "\n\n")
+	}
+	return strings.TrimSpace(cleaned)
+}
+
+// splitPgPassword 从连接字符串中取出密码，返回不含密码的连接字符串
+//
+// 同时支持 key=value 形式和 postgres:// URL 形式。
+func splitPgPassword(dsn string) (string, string) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil || u.User == nil {
+			return dsn, ""
+		}
+		password, _ := u.User.Password()
+		u.User = url.User(u.User.Username())
+		return u.String(), password
+	}
+
+	var fields []string
+	var password string
+	for _, field := range strings.Fields(dsn) {
+		if value, ok := strings.CutPrefix(field, "password="); ok {
+			password = value
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return strings.Join(fields, " "), password
+}
+
+// DumpSchema 使用 SHOW CREATE TABLE 导出数据库结构
+func (d mysqlDialect) DumpSchema(ctx context.Context, db *sql.DB, dsn string, exclude []string) ([]string, error) {
+	tables, err := d.Tables(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	// 表按名称排序，外键可能引用之后才创建的表
+	statements := []string{"SET FOREIGN_KEY_CHECKS = 0"}
+	for _, table := range tables {
+		if containsString(exclude, table) {
+			continue
+		}
+		var name, create string
+		query := fmt.Sprintf("SHOW CREATE TABLE `%s`", strings.ReplaceAll(table, "`", "``"))
+		if err := db.QueryRowContext(ctx, query).Scan(&name, &create); err != nil {
+			return nil, fmt.Errorf("读取表 %s 结构失败: %w", table, err)
+		}
+		statements = append(statements, mysqlAutoIncrement.ReplaceAllString(create, ""))
+	}
+	return append(statements, "SET FOREIGN_KEY_CHECKS = 1"), nil
+}
+
+// DumpSchema 读取 sqlite_master 中的建表语句
+func (sqliteDialect) DumpSchema(ctx context.Context, db *sql.DB, dsn string, exclude []string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT tbl_name, sql FROM sqlite_master
+WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 WHEN 'view' THEN 2 ELSE 3 END, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statements []string
+	for rows.Next() {
+		var table, stmt string
+		if err := rows.Scan(&table, &stmt); err != nil {
+			return nil, err
+		}
+		if containsString(exclude, table) {
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+	return statements, rows.Err()
+}
+
+// containsString 检查切片中是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
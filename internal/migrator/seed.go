@@ -0,0 +1,349 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parker/ParkerCli/internal/utils"
+	"github.com/parker/ParkerCli/pkg/logger"
+)
+
+// Seed 表示单个种子数据文件
+type Seed struct {
+	ID           string          // 种子ID（时间戳）
+	Name         string          // 种子名称
+	FilePath     string          // 文件路径
+	Environments []string        // 允许运行的环境，为空表示生产环境以外的所有环境
+	Enabled      bool            // 是否在当前环境中运行
+	Status       MigrationStatus // 执行状态，PENDING 或 APPLIED
+	AppliedAt    time.Time       // 执行时间
+	Checksum     string          // 种子文件内容的SHA-256
+	Drifted      bool            // 执行后文件内容是否被修改
+}
+
+// productionEnvironment 生产环境名称，未限定环境的种子不会在生产环境中执行
+const productionEnvironment = "production"
+
+// SeedService 种子数据服务接口
+type SeedService interface {
+	CreateSeed(name string, environments []string) (*Seed, error)
+	RunSeeds() ([]Seed, error)
+	SeedStatus() ([]Seed, error)
+}
+
+// SetEnvironment 设置运行种子数据时使用的环境
+func (m *StandardMigrator) SetEnvironment(env string) {
+	m.environment = env
+}
+
+// CreateSeed 创建新的种子数据文件
+func (m *StandardMigrator) CreateSeed(name string, environments []string) (*Seed, error) {
+	logger.Info("创建种子数据: %s", name)
+
+	if err := utils.EnsureDir(m.seedsDir); err != nil {
+		return nil, fmt.Errorf("创建种子目录失败: %w", err)
+	}
+
+	id := time.Now().Format("20060102150405")
+	formattedName := strings.ToLower(strings.ReplaceAll(name, " ", "_"))
+	filePath := filepath.Join(m.seedsDir, fmt.Sprintf("%s_%s.sql", id, formattedName))
+
+	envLine := ""
+	if len(environments) > 0 {
+		envLine = directivePrefix + directiveEnvironment + " " + strings.Join(environments, " ") + "\n"
+	}
+
+	content := fmt.Sprintf(`-- 种子数据: %s
+-- 创建时间: %s
+-- 说明: 每个种子只会执行一次，通过 -- +migrate Environment 限定运行环境，
+--       未限定时在生产环境以外的所有环境中执行
+%s
+-- +migrate Up
+INSERT INTO example (name) VALUES ('example');
+`, formattedName, time.Now().Format("2006-01-02 15:04:05"), envLine)
+
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return nil, fmt.Errorf("写入种子文件失败: %w", err)
+	}
+
+	logger.Info("成功创建种子文件: %s", filePath)
+
+	return &Seed{
+		ID:           id,
+		Name:         formattedName,
+		FilePath:     filePath,
+		Environments: environments,
+		Enabled:      seedEnabled(environments, m.environment),
+		Status:       StatusPending,
+	}, nil
+}
+
+// RunSeeds 执行当前环境中尚未执行的种子数据，返回本次执行的种子
+//
+// 每个种子在独立事务中执行，执行记录与数据在同一事务中提交，
+// 已执行的种子不会重复执行，因此可以安全地多次运行。
+func (m *StandardMigrator) RunSeeds() ([]Seed, error) {
+	logger.Info("执行种子数据, 环境: %s", m.environment)
+
+	ctx := context.Background()
+	var executed []Seed
+	err := m.withLock(ctx, func() error {
+		seeds, err := m.loadSeeds(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, seed := range seeds {
+			if seed.Drifted {
+				logger.Warn("种子 %s (%s) 在执行后被修改，不会重新执行", seed.Name, seed.ID)
+			}
+			if !seed.Enabled || seed.Status == StatusApplied {
+				continue
+			}
+
+			logger.Info("执行种子: %s (%s)", seed.Name, seed.ID)
+			if err := m.applySeed(ctx, seed); err != nil {
+				return fmt.Errorf("执行种子 %s 失败: %w", seed.ID, err)
+			}
+			executed = append(executed, seed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(executed) == 0 {
+		logger.Info("没有需要执行的种子数据")
+	} else {
+		logger.Info("成功执行 %d 个种子", len(executed))
+	}
+	return executed, nil
+}
+
+// SeedStatus 获取种子数据的执行状态
+func (m *StandardMigrator) SeedStatus() ([]Seed, error) {
+	logger.Info("获取种子数据状态")
+
+	ctx := context.Background()
+	if err := m.openDB(ctx); err != nil {
+		return nil, err
+	}
+	return m.loadSeeds(ctx)
+}
+
+// applySeed 执行单个种子并写入执行记录
+func (m *StandardMigrator) applySeed(ctx context.Context, seed Seed) error {
+	parsed, err := ParseMigrationFile(seed.FilePath)
+	if err != nil {
+		return err
+	}
+
+	if parsed.NoTransaction {
+		if err := execStatements(ctx, m.db, parsed.UpStatements); err != nil {
+			return err
+		}
+		return m.insertSeedRecord(ctx, m.db, seed)
+	}
+
+	return m.inTransaction(ctx, func(exec execer) error {
+		if err := execStatements(ctx, exec, parsed.UpStatements); err != nil {
+			return err
+		}
+		return m.insertSeedRecord(ctx, exec, seed)
+	})
+}
+
+// loadSeeds 查找种子文件并结合执行记录填充状态
+func (m *StandardMigrator) loadSeeds(ctx context.Context) ([]Seed, error) {
+	seeds, err := m.findSeeds()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.ensureSeedTable(ctx); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT version, checksum, applied_at FROM %s", m.seedTable)
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("查询种子执行记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	type seedRecord struct {
+		checksum  string
+		appliedAt time.Time
+	}
+	records := make(map[string]seedRecord)
+	for rows.Next() {
+		var version string
+		var r seedRecord
+		if err := rows.Scan(&version, &r.checksum, &r.appliedAt); err != nil {
+			return nil, fmt.Errorf("读取种子执行记录失败: %w", err)
+		}
+		records[version] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取种子执行记录失败: %w", err)
+	}
+
+	for i := range seeds {
+		if r, ok := records[seeds[i].ID]; ok {
+			seeds[i].Status = StatusApplied
+			seeds[i].AppliedAt = r.appliedAt
+			seeds[i].Drifted = r.checksum != seeds[i].Checksum
+		}
+	}
+
+	return seeds, nil
+}
+
+// findSeeds 查找所有种子文件
+func (m *StandardMigrator) findSeeds() ([]Seed, error) {
+	if err := utils.EnsureDir(m.seedsDir); err != nil {
+		return nil, fmt.Errorf("检查种子目录失败: %w", err)
+	}
+
+	entries, err := os.ReadDir(m.seedsDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取种子目录失败: %w", err)
+	}
+
+	var seeds []Seed
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(fileName, ".") {
+			continue
+		}
+
+		id, name, seedType, ok := parseMigrationFileName(fileName)
+		if !ok || seedType != TypeSQL {
+			logger.Warn("跳过文件名格式不正确的种子文件: %s (应为 <14位时间戳>_<名称>.sql)", fileName)
+			continue
+		}
+
+		if other, ok := seen[id]; ok {
+			return nil, fmt.Errorf("种子ID重复: %s (%s 与 %s)", id, other, fileName)
+		}
+		seen[id] = fileName
+
+		filePath := filepath.Join(m.seedsDir, fileName)
+		parsed, err := ParseMigrationFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		checksum, err := fileChecksum(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		seeds = append(seeds, Seed{
+			ID:           id,
+			Name:         name,
+			FilePath:     filePath,
+			Environments: parsed.Environments,
+			Enabled:      seedEnabled(parsed.Environments, m.environment),
+			Status:       StatusPending,
+			Checksum:     checksum,
+		})
+	}
+
+	sort.Slice(seeds, func(i, j int) bool {
+		return seeds[i].ID < seeds[j].ID
+	})
+
+	return seeds, nil
+}
+
+// seedEnabled 判断种子是否允许在指定环境中运行
+//
+// 未限定环境的种子不在生产环境中执行，避免示例数据写入生产库，
+// 需要在生产环境中执行的种子必须显式声明 production。
+func seedEnabled(environments []string, env string) bool {
+	if len(environments) == 0 {
+		return !strings.EqualFold(env, productionEnvironment)
+	}
+	for _, e := range environments {
+		if strings.EqualFold(e, env) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureSeedTable 创建种子执行记录表(如不存在)
+func (m *StandardMigrator) ensureSeedTable(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    version VARCHAR(14) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    environment VARCHAR(32) NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`, m.seedTable)
+
+	if _, err := m.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("创建种子执行记录表失败: %w", err)
+	}
+	return nil
+}
+
+// insertSeedRecord 写入种子执行记录
+func (m *StandardMigrator) insertSeedRecord(ctx context.Context, exec execer, seed Seed) error {
+	query := fmt.Sprintf("INSERT INTO %s (version, name, checksum, environment, applied_at) VALUES (%s, %s, %s, %s, %s)",
+		m.seedTable, m.dialect.Placeholder(1), m.dialect.Placeholder(2), m.dialect.Placeholder(3),
+		m.dialect.Placeholder(4), m.dialect.Placeholder(5))
+
+	if _, err := exec.ExecContext(ctx, query, seed.ID, seed.Name, seed.Checksum, m.environment, time.Now().UTC()); err != nil {
+		return fmt.Errorf("写入种子执行记录失败: %w", err)
+	}
+	return nil
+}
+
+// FormatSeedStatus 格式化种子数据状态输出
+func FormatSeedStatus(seeds []Seed) string {
+	if len(seeds) == 0 {
+		return "没有发现种子数据文件"
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("找到 %d 个种子:\n\n", len(seeds)))
+	builder.WriteString(fmt.Sprintf("%-14s | %-30s | %-24s | %-10s | %s\n",
+		"ID", "名称", "环境", "状态", "执行时间"))
+	builder.WriteString(strings.Repeat("-", 100) + "\n")
+
+	for _, s := range seeds {
+		envs := "全部(生产环境除外)"
+		if len(s.Environments) > 0 {
+			envs = strings.Join(s.Environments, ",")
+		}
+
+		status := string(s.Status)
+		if !s.Enabled && s.Status == StatusPending {
+			status = "SKIPPED"
+		}
+
+		appliedAt := ""
+		if s.Status == StatusApplied {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+
+		builder.WriteString(fmt.Sprintf("%-14s | %-30s | %-24s | %-10s | %s\n",
+			s.ID, s.Name, envs, status, appliedAt))
+	}
+
+	for _, s := range seeds {
+		if s.Drifted {
+			builder.WriteString(fmt.Sprintf("\n警告: 种子 %s (%s) 在执行后被修改", s.ID, s.Name))
+		}
+	}
+
+	return builder.String()
+}
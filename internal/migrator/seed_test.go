@@ -0,0 +1,113 @@
+package migrator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 测试按环境执行种子数据且重复执行是安全的
+func TestRunSeeds(t *testing.T) {
+	m := newTestMigrator(t)
+	writeMigration(t, m, "20240101000000_create_users.sql", `-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+`)
+	if err := m.Up(0); err != nil {
+		t.Fatalf("向上迁移失败: %v", err)
+	}
+
+	if err := os.MkdirAll(m.seedsDir, 0755); err != nil {
+		t.Fatalf("创建种子目录失败: %v", err)
+	}
+	writeSeed := func(fileName, content string) {
+		if err := os.WriteFile(filepath.Join(m.seedsDir, fileName), []byte(content), 0644); err != nil {
+			t.Fatalf("写入种子文件失败: %v", err)
+		}
+	}
+	writeSeed("20240101000000_admin.sql", `-- +migrate Up
+INSERT INTO users (name) VALUES ('admin');
+`)
+	writeSeed("20240102000000_fixtures.sql", `-- +migrate Environment development testing
+-- +migrate Up
+INSERT INTO users (name) VALUES ('alice');
+INSERT INTO users (name) VALUES ('bob');
+`)
+	writeSeed("20240103000000_demo.sql", `-- +migrate Environment staging
+-- +migrate Up
+INSERT INTO users (name) VALUES ('demo');
+`)
+
+	countUsers := func() int {
+		var count int
+		if err := m.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+			t.Fatalf("查询用户数失败: %v", err)
+		}
+		return count
+	}
+
+	t.Run("Run", func(t *testing.T) {
+		executed, err := m.RunSeeds()
+		if err != nil {
+			t.Fatalf("执行种子失败: %v", err)
+		}
+		if len(executed) != 2 {
+			t.Errorf("testing环境应执行2个种子，实际为%d", len(executed))
+		}
+		if countUsers() != 3 {
+			t.Errorf("应插入3个用户，实际为%d", countUsers())
+		}
+	})
+
+	t.Run("Rerun", func(t *testing.T) {
+		executed, err := m.RunSeeds()
+		if err != nil {
+			t.Fatalf("重复执行种子失败: %v", err)
+		}
+		if len(executed) != 0 || countUsers() != 3 {
+			t.Errorf("重复执行不应再次插入数据")
+		}
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		seeds, err := m.SeedStatus()
+		if err != nil {
+			t.Fatalf("获取种子状态失败: %v", err)
+		}
+		if len(seeds) != 3 || seeds[2].Enabled || seeds[2].Status != StatusPending {
+			t.Errorf("其他环境的种子应为未执行: %+v", seeds)
+		}
+	})
+
+	t.Run("Other Environment", func(t *testing.T) {
+		m.SetEnvironment("staging")
+		defer m.SetEnvironment("testing")
+
+		executed, err := m.RunSeeds()
+		if err != nil {
+			t.Fatalf("执行种子失败: %v", err)
+		}
+		if len(executed) != 1 || executed[0].Name != "demo" {
+			t.Errorf("staging环境应只执行demo种子: %+v", executed)
+		}
+	})
+
+	t.Run("Production", func(t *testing.T) {
+		writeSeed("20240104000000_sample.sql", `-- +migrate Up
+INSERT INTO users (name) VALUES ('sample');
+`)
+		writeSeed("20240105000000_roles.sql", `-- +migrate Environment production
+-- +migrate Up
+INSERT INTO users (name) VALUES ('root');
+`)
+		m.SetEnvironment("production")
+		defer m.SetEnvironment("testing")
+
+		executed, err := m.RunSeeds()
+		if err != nil {
+			t.Fatalf("执行种子失败: %v", err)
+		}
+		if len(executed) != 1 || executed[0].Name != "roles" {
+			t.Errorf("生产环境应只执行显式声明production的种子: %+v", executed)
+		}
+	})
+}
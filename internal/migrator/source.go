@@ -0,0 +1,83 @@
+package migrator
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// NewStandardMigratorFromFS 创建从 fs.FS 读取迁移文件并使用已有数据库连接的迁移器
+//
+// 适用于服务在启动时执行通过 //go:embed 嵌入的迁移。迁移文件位于fsys的根目录，
+// 数据库连接由调用方管理，Close不会关闭它。嵌入的Go迁移需要通过 Register 注册。
+func NewStandardMigratorFromFS(db *sql.DB, driver string, fsys fs.FS) *StandardMigrator {
+	return &StandardMigrator{
+		target:        DefaultTarget,
+		migrationsDir: ".",
+		source:        fsys,
+		schemaTable:   "schema_migrations",
+		dbDriver:      driver,
+		db:            db,
+		externalDB:    true,
+	}
+}
+
+// SetSchemaTable 设置迁移记录表名称
+func (m *StandardMigrator) SetSchemaTable(table string) {
+	m.schemaTable = table
+}
+
+// migrationSource 返回迁移文件来源，未指定时使用磁盘上的迁移目录
+func (m *StandardMigrator) migrationSource() fs.FS {
+	if m.source != nil {
+		return m.source
+	}
+	return os.DirFS(m.migrationsDir)
+}
+
+// writableSource 检查迁移文件是否可以修改
+func (m *StandardMigrator) writableSource() error {
+	if m.source != nil {
+		return fmt.Errorf("迁移文件来自只读的 fs.FS，无法修改")
+	}
+	return nil
+}
+
+// migrationFilePath 返回迁移文件的路径，用于展示和读取
+func (m *StandardMigrator) migrationFilePath(fileName string) string {
+	if m.source != nil {
+		return fileName
+	}
+	return filepath.Join(m.migrationsDir, fileName)
+}
+
+// readMigration 读取迁移文件内容
+func (m *StandardMigrator) readMigration(migration Migration) ([]byte, error) {
+	content, err := fs.ReadFile(m.migrationSource(), path.Base(filepath.ToSlash(migration.FilePath)))
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+	}
+	return content, nil
+}
+
+// parseMigration 解析SQL迁移文件
+func (m *StandardMigrator) parseMigration(migration Migration) (*ParsedMigration, error) {
+	content, err := m.readMigration(migration)
+	if err != nil {
+		return nil, err
+	}
+	return parseMigrationContent(migration.FilePath, content)
+}
+
+// parseMigrationContent 解析迁移内容，错误信息中包含文件路径
+func parseMigrationContent(filePath string, content []byte) (*ParsedMigration, error) {
+	parsed, err := ParseMigration(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("解析迁移文件 %s 失败: %w", filePath, err)
+	}
+	return parsed, nil
+}
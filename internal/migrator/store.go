@@ -0,0 +1,238 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// appliedRecord 表示schema_migrations表中的一条记录
+type appliedRecord struct {
+	Version   string
+	Batch     int
+	AppliedAt time.Time
+	Status    MigrationStatus
+	Name      string
+	Checksum  string
+}
+
+// execer 可执行SQL的对象，*sql.DB 和 *sql.Tx 均满足
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// schemaColumn 迁移记录表的列定义
+type schemaColumn struct {
+	Name       string
+	Definition string
+}
+
+// schemaColumns 迁移记录表在初始结构之后新增的列，已有的表会自动补齐
+var schemaColumns = []schemaColumn{
+	{"status", "VARCHAR(16) NOT NULL DEFAULT 'APPLIED'"},
+	{"name", "VARCHAR(255) NOT NULL DEFAULT ''"},
+	{"checksum", "VARCHAR(64) NOT NULL DEFAULT ''"},
+}
+
+// openDB 打开数据库连接并确保迁移记录表存在
+func (m *StandardMigrator) openDB(ctx context.Context) error {
+	if m.dialect != nil {
+		return nil
+	}
+
+	d, err := newDialect(m.dbDriver)
+	if err != nil {
+		return err
+	}
+
+	// 使用调用方提供的连接时无需重新连接
+	if m.db == nil {
+		if m.dbDSN == "" {
+			return fmt.Errorf("未配置数据库连接信息")
+		}
+
+		// 迁移器不导入数据库驱动，由调用方按需导入
+		if !slices.Contains(sql.Drivers(), d.DriverName()) {
+			return fmt.Errorf("未注册数据库驱动 %s，请导入 %s", d.DriverName(), d.DriverImport())
+		}
+
+		db, err := sql.Open(d.DriverName(), m.dbDSN)
+		if err != nil {
+			return fmt.Errorf("打开数据库连接失败: %w", err)
+		}
+
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return fmt.Errorf("连接数据库失败: %w", err)
+		}
+
+		m.db = db
+	}
+
+	// dry-run模式下不修改数据库
+	if m.dryRun == nil {
+		if err := m.ensureSchemaTable(ctx); err != nil {
+			return err
+		}
+	}
+
+	m.dialect = d
+	return nil
+}
+
+// Close 关闭数据库连接
+func (m *StandardMigrator) Close() error {
+	// 清理编译的Go迁移运行器
+	if m.runner != nil {
+		os.RemoveAll(m.runner.tmpDir)
+		m.runner = nil
+	}
+
+	m.dialect = nil
+	if m.db == nil || m.externalDB {
+		return nil
+	}
+	err := m.db.Close()
+	m.db = nil
+	return err
+}
+
+// ensureSchemaTable 创建迁移记录表(如不存在)
+func (m *StandardMigrator) ensureSchemaTable(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+    version VARCHAR(14) NOT NULL PRIMARY KEY,
+    batch INTEGER NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`, m.schemaTable)
+
+	if _, err := m.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+
+	return m.ensureSchemaColumns(ctx)
+}
+
+// ensureSchemaColumns 为旧版本创建的迁移记录表补齐新增的列
+func (m *StandardMigrator) ensureSchemaColumns(ctx context.Context) error {
+	missing, err := m.missingSchemaColumns(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range missing {
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.schemaTable, column.Name, column.Definition)
+		if _, err := m.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("为迁移记录表添加列 %s 失败: %w", column.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// missingSchemaColumns 返回迁移记录表中缺少的列
+func (m *StandardMigrator) missingSchemaColumns(ctx context.Context) ([]schemaColumn, error) {
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", m.schemaTable))
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移记录表结构失败: %w", err)
+	}
+	columns, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移记录表结构失败: %w", err)
+	}
+
+	existing := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		existing[strings.ToLower(column)] = true
+	}
+
+	var missing []schemaColumn
+	for _, column := range schemaColumns {
+		if !existing[column.Name] {
+			missing = append(missing, column)
+		}
+	}
+	return missing, nil
+}
+
+// loadApplied 读取已应用的迁移记录
+func (m *StandardMigrator) loadApplied(ctx context.Context) (map[string]appliedRecord, error) {
+	records := make(map[string]appliedRecord)
+
+	// dry-run模式下不会创建迁移记录表，表不存在时视为没有已应用的迁移
+	if m.dryRun != nil {
+		exists, err := m.dialect.TableExists(ctx, m.db, m.schemaTable)
+		if err != nil {
+			return nil, fmt.Errorf("检查迁移记录表失败: %w", err)
+		}
+		if !exists {
+			return records, nil
+		}
+
+		missing, err := m.missingSchemaColumns(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("迁移记录表 %s 结构需要升级，请先执行一次 migrate status", m.schemaTable)
+		}
+	}
+
+	query := fmt.Sprintf("SELECT version, batch, applied_at, status, name, checksum FROM %s", m.schemaTable)
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r appliedRecord
+		var status string
+		if err := rows.Scan(&r.Version, &r.Batch, &r.AppliedAt, &status, &r.Name, &r.Checksum); err != nil {
+			return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+		}
+		r.Status = MigrationStatus(status)
+		records[r.Version] = r
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+	}
+
+	return records, nil
+}
+
+// insertRecord 写入迁移记录
+func (m *StandardMigrator) insertRecord(ctx context.Context, exec execer, migration Migration, batch int, status MigrationStatus) error {
+	query := fmt.Sprintf("INSERT INTO %s (version, batch, applied_at, status, name, checksum) VALUES (%s, %s, %s, %s, %s, %s)",
+		m.schemaTable, m.dialect.Placeholder(1), m.dialect.Placeholder(2), m.dialect.Placeholder(3),
+		m.dialect.Placeholder(4), m.dialect.Placeholder(5), m.dialect.Placeholder(6))
+
+	if _, err := exec.ExecContext(ctx, query, migration.ID, batch, time.Now().UTC(), string(status),
+		migration.Name, migration.Checksum); err != nil {
+		return fmt.Errorf("写入迁移记录失败: %w", err)
+	}
+	return nil
+}
+
+// deleteRecord 删除迁移记录
+func (m *StandardMigrator) deleteRecord(ctx context.Context, exec execer, version string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE version = %s", m.schemaTable, m.dialect.Placeholder(1))
+
+	if _, err := exec.ExecContext(ctx, query, version); err != nil {
+		return fmt.Errorf("删除迁移记录失败: %w", err)
+	}
+	return nil
+}
+
+// saveRecord 写入或覆盖迁移记录
+func (m *StandardMigrator) saveRecord(ctx context.Context, exec execer, migration Migration, batch int, status MigrationStatus) error {
+	if err := m.deleteRecord(ctx, exec, migration.ID); err != nil {
+		return err
+	}
+	return m.insertRecord(ctx, exec, migration, batch, status)
+}
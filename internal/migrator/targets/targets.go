@@ -0,0 +1,109 @@
+// Package targets 根据配置文件创建各数据库的迁移器
+//
+// 迁移引擎(migrator 包)不读取配置，命令行通过本包按 database 和 databases
+// 配置块创建迁移器。
+package targets
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parker/ParkerCli/internal/config"
+	"github.com/parker/ParkerCli/internal/migrator"
+)
+
+// New 创建使用指定数据库的迁移器
+//
+// name为空或为 default 时使用 database 配置块，否则使用 databases 中的同名配置。
+func New(name string) (*migrator.StandardMigrator, error) {
+	opts, err := options(name)
+	if err != nil {
+		return nil, err
+	}
+	return migrator.NewStandardMigrator(opts), nil
+}
+
+// Names 返回配置的所有数据库名称，没有配置 databases 时只有默认数据库
+func Names() []string {
+	databases := config.GetAll().Databases
+	if len(databases) == 0 {
+		return []string{migrator.DefaultTarget}
+	}
+
+	names := make([]string, 0, len(databases))
+	for name := range databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// options 根据配置生成指定数据库的迁移器选项
+func options(name string) (migrator.Options, error) {
+	opts := migrator.Options{
+		Environment:     config.GetString("environment"),
+		LockTimeout:     time.Duration(config.GetInt("migrate.lock_timeout")) * time.Second,
+		AllowOutOfOrder: config.GetBool("migrate.allow_out_of_order"),
+	}
+
+	if name == "" || name == migrator.DefaultTarget {
+		dbConfig := config.GetAll().Database
+		opts.Target = migrator.DefaultTarget
+		opts.Driver = dbConfig.Driver
+		opts.DSN = DSN(dbConfig)
+		opts.MigrationsDir = config.GetString("paths.migrations")
+		opts.SeedsDir = config.GetString("paths.seeds")
+		opts.SchemaFile = config.GetString("paths.schema")
+		return opts, nil
+	}
+
+	target, ok := config.GetAll().Databases[name]
+	if !ok {
+		return opts, fmt.Errorf("未配置数据库: %s", name)
+	}
+	if target.SSLMode == "" {
+		target.SSLMode = config.DefaultConfig.Database.SSLMode
+	}
+	opts.Target = name
+	opts.Driver = target.Driver
+	opts.DSN = DSN(target.DatabaseConfig)
+
+	// 未指定目录时使用默认目录下与名称同名的子目录
+	opts.MigrationsDir = target.Migrations
+	if opts.MigrationsDir == "" {
+		opts.MigrationsDir = filepath.Join(config.GetString("paths.migrations"), name)
+	}
+	opts.SeedsDir = target.Seeds
+	if opts.SeedsDir == "" {
+		opts.SeedsDir = filepath.Join(config.GetString("paths.seeds"), name)
+	}
+	opts.SchemaFile = target.Schema
+	if opts.SchemaFile == "" && config.GetString("paths.schema") != "" {
+		opts.SchemaFile = filepath.Join(filepath.Dir(config.GetString("paths.schema")), name+".sql")
+	}
+	return opts, nil
+}
+
+// DSN 根据数据库配置生成连接字符串
+func DSN(dbConfig config.DatabaseConfig) string {
+	switch dbConfig.Driver {
+	case "postgres", "postgresql":
+		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			dbConfig.Host, dbConfig.Port, dbConfig.User, dbConfig.Password, dbConfig.Name, dbConfig.SSLMode)
+	case "mysql":
+		return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
+			dbConfig.User, dbConfig.Password, dbConfig.Host, dbConfig.Port, dbConfig.Name)
+	case "sqlite", "sqlite3":
+		// SQLite使用name作为数据库文件路径
+		dsn := dbConfig.Name
+		if dsn != "" && !strings.Contains(dsn, "?") {
+			dsn += "?_pragma=busy_timeout(5000)"
+		}
+		return dsn
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,72 @@
+package targets
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parker/ParkerCli/internal/config"
+	"github.com/parker/ParkerCli/internal/migrator"
+)
+
+// 测试按名称生成使用不同数据库的迁移器选项
+func TestOptions(t *testing.T) {
+	config.Reset()
+	config.Set("database.driver", "sqlite")
+	config.Set("database.name", "./default.db")
+	config.Set("databases", map[string]interface{}{
+		"primary": map[string]interface{}{
+			"driver":     "sqlite",
+			"name":       "./primary.db",
+			"migrations": "./db/primary",
+		},
+		"analytics": map[string]interface{}{
+			"driver": "postgres",
+			"host":   "analytics.local",
+			"port":   5432,
+			"name":   "events",
+		},
+	})
+	defer config.Reset()
+
+	if names := strings.Join(Names(), ","); names != "analytics,primary" {
+		t.Errorf("数据库名称不正确: %s", names)
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		opts, err := options("")
+		if err != nil {
+			t.Fatalf("生成迁移器选项失败: %v", err)
+		}
+		if opts.Target != migrator.DefaultTarget || !strings.HasPrefix(opts.DSN, "./default.db") {
+			t.Errorf("应使用 database 配置: %s %s", opts.Target, opts.DSN)
+		}
+	})
+
+	t.Run("Named", func(t *testing.T) {
+		opts, err := options("primary")
+		if err != nil {
+			t.Fatalf("生成迁移器选项失败: %v", err)
+		}
+		if opts.MigrationsDir != "./db/primary" || !strings.HasPrefix(opts.DSN, "./primary.db") {
+			t.Errorf("应使用 primary 配置: %s %s", opts.MigrationsDir, opts.DSN)
+		}
+
+		opts, err = options("analytics")
+		if err != nil {
+			t.Fatalf("生成迁移器选项失败: %v", err)
+		}
+		if opts.MigrationsDir != filepath.Join("migrations", "analytics") {
+			t.Errorf("未配置迁移目录时应使用同名子目录: %s", opts.MigrationsDir)
+		}
+		if !strings.Contains(opts.DSN, "host=analytics.local") || !strings.Contains(opts.DSN, "sslmode=disable") {
+			t.Errorf("连接字符串不正确: %s", opts.DSN)
+		}
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		if _, err := New("missing"); err == nil {
+			t.Errorf("未配置的数据库应返回错误")
+		}
+	})
+}
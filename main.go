@@ -1,19 +1,38 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/urfave/cli/v2"
 
 	"github.com/parker/ParkerCli/cmd"
+	"github.com/parker/ParkerCli/pkg/version"
 )
 
+// defaultVersion 未注入版本号且不是通过 go install 安装时使用的版本号
+const defaultVersion = "0.1.0"
+
+// buildInfo 返回 ParkerCli 的构建信息
+func buildInfo() version.Info {
+	info := version.Get()
+	if info.Version == version.DevVersion {
+		info.Version = defaultVersion
+	}
+	return info
+}
+
 func main() {
+	// --version 输出完整的构建信息，便于定位具体的构建
+	cli.VersionPrinter = func(c *cli.Context) {
+		fmt.Printf("%s version %s\n", c.App.Name, buildInfo())
+	}
+
 	app := &cli.App{
 		Name:    "ParkerCli",
 		Usage:   "一款面向 Go 后端开发调试、部署、发布的全能 CLI 工具",
-		Version: "0.1.0",
+		Version: buildInfo().Version,
 		Commands: []*cli.Command{
 			cmd.DebugCommand,
 			cmd.RunCommand,
@@ -28,7 +47,7 @@ func main() {
 				Aliases: []string{"v"},
 				Usage:   "查看当前 ParkerCli 版本",
 				Action: func(c *cli.Context) error {
-					log.Printf("ParkerCli version: %s", c.App.Version)
+					log.Printf("ParkerCli version: %s", buildInfo())
 					return nil
 				},
 			},
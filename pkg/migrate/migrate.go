@@ -0,0 +1,159 @@
+// Package migrate 在服务进程中执行与 ParkerCli migrate 相同的数据库迁移
+//
+// 迁移文件通常通过 //go:embed 嵌入服务二进制，服务启动时执行：
+//
+//	//go:embed migrations
+//	var migrationsFS embed.FS
+//
+//	m, err := migrate.New(db, migrationsFS, "postgres", migrate.WithDir("migrations"))
+//	if err != nil {
+//		return err
+//	}
+//	if err := m.Up(0); err != nil {
+//		return err
+//	}
+//
+// 本包不读取配置文件，也不导入数据库驱动，打开 db 时使用的驱动由服务自行导入。
+package migrate
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/parker/ParkerCli/internal/migrator"
+)
+
+type (
+	// Migration 表示单个迁移及其状态
+	Migration = migrator.Migration
+	// MigrationStatus 表示迁移状态
+	MigrationStatus = migrator.MigrationStatus
+	// GoMigrationFunc Go函数迁移的签名
+	GoMigrationFunc = migrator.GoMigrationFunc
+)
+
+const (
+	// StatusPending 表示待执行
+	StatusPending = migrator.StatusPending
+	// StatusApplied 表示已应用
+	StatusApplied = migrator.StatusApplied
+	// StatusFailed 表示执行失败
+	StatusFailed = migrator.StatusFailed
+	// StatusMissing 表示已应用但迁移文件已被删除
+	StatusMissing = migrator.StatusMissing
+)
+
+// Register 注册Go函数迁移，嵌入的Go迁移必须通过它注册
+func Register(id string, up, down GoMigrationFunc) {
+	migrator.Register(id, up, down)
+}
+
+// Migrator 基于 fs.FS 和已有数据库连接的迁移器
+type Migrator struct {
+	engine *migrator.StandardMigrator
+}
+
+// options 迁移器选项
+type options struct {
+	dir             string
+	schemaTable     string
+	lockTimeout     time.Duration
+	allowOutOfOrder bool
+}
+
+// Option 迁移器选项函数类型
+type Option func(*options)
+
+// WithDir 设置迁移文件在fs.FS中的目录，默认为根目录
+func WithDir(dir string) Option {
+	return func(o *options) {
+		o.dir = dir
+	}
+}
+
+// WithSchemaTable 设置迁移记录表名称，默认为 schema_migrations
+func WithSchemaTable(table string) Option {
+	return func(o *options) {
+		o.schemaTable = table
+	}
+}
+
+// WithLockTimeout 设置等待迁移锁的超时时间，默认为60秒
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.lockTimeout = timeout
+	}
+}
+
+// WithAllowOutOfOrder 允许应用早于最新已应用迁移的待执行迁移
+func WithAllowOutOfOrder(allow bool) Option {
+	return func(o *options) {
+		o.allowOutOfOrder = allow
+	}
+}
+
+// New 创建迁移器
+//
+// driver 为 postgres、mysql 或 sqlite，与db使用的数据库一致。
+// db的生命周期由调用方管理，迁移器不会关闭它。
+func New(db *sql.DB, fsys fs.FS, driver string, opts ...Option) (*Migrator, error) {
+	if db == nil {
+		return nil, fmt.Errorf("数据库连接不能为空")
+	}
+
+	o := options{schemaTable: "schema_migrations"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.dir != "" && o.dir != "." {
+		sub, err := fs.Sub(fsys, o.dir)
+		if err != nil {
+			return nil, fmt.Errorf("打开迁移目录 %s 失败: %w", o.dir, err)
+		}
+		fsys = sub
+	}
+
+	engine := migrator.NewStandardMigratorFromFS(db, driver, fsys)
+	engine.SetSchemaTable(o.schemaTable)
+	engine.SetLockTimeout(o.lockTimeout)
+	engine.SetAllowOutOfOrder(o.allowOutOfOrder)
+
+	return &Migrator{engine: engine}, nil
+}
+
+// Up 应用待执行的迁移，steps为0表示全部
+func (m *Migrator) Up(steps int) error {
+	return m.engine.Up(steps)
+}
+
+// Down 回滚已应用的迁移，steps为0表示全部
+func (m *Migrator) Down(steps int) error {
+	return m.engine.Down(steps)
+}
+
+// Goto 迁移到指定版本
+func (m *Migrator) Goto(version string) error {
+	return m.engine.Goto(version)
+}
+
+// Status 获取迁移状态
+func (m *Migrator) Status() ([]Migration, error) {
+	return m.engine.Status()
+}
+
+// Validate 校验已应用迁移的文件是否被修改或删除，返回存在问题的迁移
+func (m *Migrator) Validate() ([]Migration, error) {
+	return m.engine.Validate()
+}
+
+// Up 使用默认选项应用fsys中所有待执行的迁移，适合在服务启动时调用
+func Up(db *sql.DB, fsys fs.FS, driver string, opts ...Option) error {
+	m, err := New(db, fsys, driver, opts...)
+	if err != nil {
+		return err
+	}
+	return m.Up(0)
+}
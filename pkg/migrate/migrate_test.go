@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "modernc.org/sqlite"
+)
+
+// 测试从 fs.FS 读取迁移并使用已有数据库连接执行
+func TestMigrateFromFS(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"migrations/20240101000000_create_users.sql": {Data: []byte(`-- +migrate Up
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+
+-- +migrate Down
+DROP TABLE users;
+`)},
+		"migrations/20240102000000_add_admin.go": {Data: []byte("package migrations\n")},
+		"migrations/README.md":                   {Data: []byte("说明")},
+	}
+
+	Register("20240102000000", func(ctx context.Context, db *sql.DB) error {
+		_, err := db.ExecContext(ctx, "INSERT INTO users (name) VALUES ('admin')")
+		return err
+	}, func(ctx context.Context, db *sql.DB) error {
+		_, err := db.ExecContext(ctx, "DELETE FROM users WHERE name = 'admin'")
+		return err
+	})
+
+	t.Run("Up", func(t *testing.T) {
+		if err := Up(db, fsys, "sqlite", WithDir("migrations")); err != nil {
+			t.Fatalf("执行迁移失败: %v", err)
+		}
+
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+			t.Fatalf("查询失败: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("应插入1个用户，实际为%d", count)
+		}
+	})
+
+	t.Run("Status And Down", func(t *testing.T) {
+		m, err := New(db, fsys, "sqlite", WithDir("migrations"))
+		if err != nil {
+			t.Fatalf("创建迁移器失败: %v", err)
+		}
+
+		migrations, err := m.Status()
+		if err != nil {
+			t.Fatalf("获取迁移状态失败: %v", err)
+		}
+		if len(migrations) != 2 || migrations[0].Status != StatusApplied || migrations[1].Status != StatusApplied {
+			t.Fatalf("所有迁移应为已应用: %+v", migrations)
+		}
+
+		if err := m.Down(0); err != nil {
+			t.Fatalf("回滚迁移失败: %v", err)
+		}
+
+		// 数据库连接由调用方管理，迁移后仍可使用
+		if err := db.Ping(); err != nil {
+			t.Errorf("迁移器不应关闭数据库连接: %v", err)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+// Package version 保存构建时通过 -ldflags 注入的版本和构建信息
+//
+// 使用 ParkerCli build code / release build 构建时，将配置中的
+// build.version_package 设为本包路径即可自动注入：
+//
+//	build:
+//	  version_package: github.com/parker/ParkerCli/pkg/version
+//
+// 应用中通过 version.Get() 读取，例如在 --version 中输出 version.Get().String()。
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// DevVersion 未注入版本号时 Version 的默认值
+const DevVersion = "dev"
+
+// 以下变量在构建时通过 -X 注入，只能是字符串
+var (
+	// Version 版本号
+	Version = DevVersion
+	// Commit 构建时的git提交SHA
+	Commit = ""
+	// Dirty 构建时工作区是否有未提交的修改，取值为 "true" 或 "false"
+	Dirty = ""
+	// Branch 构建时的git分支
+	Branch = ""
+	// BuildTime 构建时间(RFC3339, UTC)
+	BuildTime = ""
+	// GoVersion 构建使用的Go版本
+	GoVersion = ""
+	// BuildUser 执行构建的用户
+	BuildUser = ""
+)
+
+// Info 构建信息
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Dirty     bool   `json:"dirty"`
+	Branch    string `json:"branch,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+	BuildUser string `json:"build_user,omitempty"`
+}
+
+// Get 返回当前程序的构建信息
+//
+// 未通过 -ldflags 注入版本号时，使用 go install 记录的模块版本；
+// 未注入提交信息时，使用 go build 自动记录的 vcs 信息。
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Dirty:     Dirty == "true",
+		Branch:    Branch,
+		BuildTime: BuildTime,
+		GoVersion: GoVersion,
+		BuildUser: BuildUser,
+	}
+
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	if info.Version == DevVersion {
+		if v := moduleVersion(bi); v != "" {
+			info.Version = v
+		}
+	}
+
+	if info.Commit == "" {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.modified":
+				info.Dirty = setting.Value == "true"
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+// moduleVersion 返回主模块的版本号，本地构建(go build、go run)没有版本号时返回空字符串
+func moduleVersion(bi *debug.BuildInfo) string {
+	if bi.Main.Version == "" || bi.Main.Version == "(devel)" {
+		return ""
+	}
+	return strings.TrimPrefix(bi.Main.Version, "v")
+}
+
+// ShortCommit 返回提交SHA的前12位
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
+
+// String 返回单行的构建信息，如:
+//
+//	1.2.0 (commit 3f2c1a9b0d4e-dirty, branch main, built 2024-01-02T15:04:05Z by ci, go1.22.2 linux/amd64)
+func (i Info) String() string {
+	var details []string
+	if i.Commit != "" {
+		commit := "commit " + i.ShortCommit()
+		if i.Dirty {
+			commit += "-dirty"
+		}
+		details = append(details, commit)
+	}
+	if i.Branch != "" {
+		details = append(details, "branch "+i.Branch)
+	}
+	if i.BuildTime != "" {
+		built := "built " + i.BuildTime
+		if i.BuildUser != "" {
+			built += " by " + i.BuildUser
+		}
+		details = append(details, built)
+	}
+	details = append(details, fmt.Sprintf("%s %s/%s", i.GoVersion, runtime.GOOS, runtime.GOARCH))
+
+	return fmt.Sprintf("%s (%s)", i.Version, strings.Join(details, ", "))
+}
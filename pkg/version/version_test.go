@@ -0,0 +1,54 @@
+package version
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+// 测试构建信息的输出格式
+func TestInfoString(t *testing.T) {
+	info := Info{
+		Version:   "1.2.0",
+		Commit:    "3f2c1a9b0d4e5f60718293a4b5c6d7e8f9012345",
+		Dirty:     true,
+		Branch:    "main",
+		BuildTime: "2024-01-02T15:04:05Z",
+		GoVersion: "go1.22.2",
+		BuildUser: "ci",
+	}
+
+	got := info.String()
+	for _, want := range []string{"1.2.0 (", "commit 3f2c1a9b0d4e-dirty", "branch main", "built 2024-01-02T15:04:05Z by ci", "go1.22.2"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("输出缺少 %q: %s", want, got)
+		}
+	}
+
+	if got := (Info{Version: "dev", GoVersion: "go1.22.2"}).String(); strings.Contains(got, "commit") {
+		t.Errorf("没有提交信息时不应输出commit: %s", got)
+	}
+}
+
+// 测试读取 go install 记录的模块版本
+func TestModuleVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{"Install", "v1.2.0", "1.2.0"},
+		{"Pseudo Version", "v0.0.0-20240102150405-3f2c1a9b0d4e", "0.0.0-20240102150405-3f2c1a9b0d4e"},
+		{"Local Build", "(devel)", ""},
+		{"Empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bi := &debug.BuildInfo{Main: debug.Module{Path: "github.com/parker/ParkerCli", Version: tt.version}}
+			if got := moduleVersion(bi); got != tt.want {
+				t.Errorf("结果为 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}